@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"github.com/nDmitry/tgfeed/internal/api/rest"
@@ -42,27 +43,18 @@ func main() {
 		port = "8080"
 	}
 
-	redisHost := os.Getenv("REDIS_HOST")
-
-	if redisHost == "" {
-		redisHost = "redis"
-	}
-
-	// Initialize Redis cache
-	redisClient, err := cache.NewRedisClient(ctx, fmt.Sprintf("%s:6379", redisHost))
+	c, err := newCache(ctx)
 
 	if err != nil {
-		logger.Error("Failed to connect to Redis", "error", err)
+		logger.Error("Failed to initialize cache", "error", err)
 		os.Exit(1)
 	}
 
-	defer redisClient.Close()
-
 	scraper := feed.NewDefaultScraper()
 	generator := &feed.Generator{}
 
 	// Initialize and run the HTTP server
-	server := rest.NewServer(redisClient, scraper, generator, port)
+	server := rest.NewServer(c, scraper, generator, port)
 
 	if err := server.Run(ctx); err != nil {
 		logger.Error("Server error", "error", err)
@@ -71,3 +63,68 @@ func main() {
 
 	logger.Info("Server exited gracefully")
 }
+
+// newCache creates the cache backend set with CACHE_BACKEND, Redis by default
+func newCache(ctx context.Context) (cache.Cache, error) {
+	backend := os.Getenv("CACHE_BACKEND")
+
+	switch backend {
+	case "", "redis":
+		redisHost := os.Getenv("REDIS_HOST")
+
+		if redisHost == "" {
+			redisHost = "redis"
+		}
+
+		redisClient, err := cache.NewRedisClient(ctx, fmt.Sprintf("%s:6379", redisHost))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+
+		return redisClient, nil
+	case "memory":
+		maxBytes, err := cacheMaxBytes(cache.MemoryCacheMaxBytesDefault)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return cache.NewMemoryCache(maxBytes), nil
+	case "file":
+		maxBytes, err := cacheMaxBytes(cache.FileCacheMaxBytesDefault)
+
+		if err != nil {
+			return nil, err
+		}
+
+		dir := os.Getenv("CACHE_DIR")
+
+		if dir == "" {
+			dir = "cache"
+		}
+
+		return cache.NewFileCache(dir, maxBytes, cache.FileCacheSweepInterval)
+	case "none":
+		return cache.NoopCache{}, nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q, must be memory, file, redis or none", backend)
+	}
+}
+
+// cacheMaxBytes reads the cache size limit set in megabytes with CACHE_MAX_SIZE
+func cacheMaxBytes(defaultBytes int64) (int64, error) {
+	v := os.Getenv("CACHE_MAX_SIZE")
+
+	if v == "" {
+		return defaultBytes, nil
+	}
+
+	mb, err := strconv.ParseInt(v, 10, 64)
+
+	if err != nil || mb <= 0 {
+		return 0, fmt.Errorf("CACHE_MAX_SIZE must be a positive number of megabytes")
+	}
+
+	return mb << 20, nil
+}
@@ -8,6 +8,7 @@ require (
 	github.com/gorilla/feeds v1.1.2
 	github.com/redis/go-redis/v9 v9.7.3
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/sync v0.13.0
 )
 
 require (
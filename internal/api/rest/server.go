@@ -2,10 +2,12 @@ package rest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/nDmitry/tgfeed/internal/app"
@@ -21,6 +23,8 @@ type Server struct {
 	scraper   Scraper
 	generator Generator
 	port      string
+	// Scrapes going on after their requests are served
+	background sync.WaitGroup
 }
 
 // NewServer creates a new REST API server
@@ -52,7 +56,7 @@ func NewServer(c cache.Cache, s Scraper, g Generator, port string) *Server {
 
 // registerHandlers sets up all API routes
 func (s *Server) registerHandlers() {
-	NewTelegramHandler(s.mux, s.cache, s.scraper, s.generator)
+	NewTelegramHandler(s.mux, s.cache, s.scraper, s.generator, &s.background)
 	// more handlers can be here
 }
 
@@ -86,7 +90,12 @@ func (s *Server) Run(ctx context.Context) error {
 	// Wait for context cancellation or server error
 	select {
 	case err := <-errCh:
-		return err
+		// A nil error means the server was shut down, which has closed the cache already
+		if err != nil {
+			return errors.Join(err, s.closeCache())
+		}
+
+		return nil
 	case <-ctx.Done():
 		return s.Shutdown(context.Background())
 	}
@@ -100,11 +109,48 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	if err := s.server.Shutdown(shutdownCtx); err != nil {
-		return fmt.Errorf("server forced to shutdown: %w", err)
+	err := s.server.Shutdown(shutdownCtx)
+
+	if err != nil {
+		err = fmt.Errorf("server forced to shutdown: %w", err)
+	}
+
+	// Background scrapes cache their channels, so the cache is closed after them
+	if !s.waitBackground(shutdownCtx) {
+		s.logger.Warn("Closing the cache before background scrapes have finished")
+	}
+
+	// Pending cache writes are flushed even if some requests are still running
+	if err := errors.Join(err, s.closeCache()); err != nil {
+		return err
 	}
 
 	s.logger.Info("Server exited gracefully")
 
 	return nil
 }
+
+// waitBackground waits for the background scrapes until the context is done
+func (s *Server) waitBackground(ctx context.Context) bool {
+	done := make(chan struct{})
+
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Server) closeCache() error {
+	if err := s.cache.Close(); err != nil {
+		return fmt.Errorf("failed to close cache: %w", err)
+	}
+
+	return nil
+}
@@ -3,46 +3,116 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nDmitry/tgfeed/internal/app"
 	"github.com/nDmitry/tgfeed/internal/cache"
 	"github.com/nDmitry/tgfeed/internal/entity"
+	"golang.org/x/sync/singleflight"
 )
 
 type Scraper interface {
-	Scrape(ctx context.Context, username string) (*entity.Channel, error)
+	Scrape(ctx context.Context, username string, params entity.ScrapeParams) (*entity.Channel, error)
+	ScrapePost(ctx context.Context, username string, postID int) (*entity.Channel, error)
 }
 
 type Generator interface {
 	Generate(channel *entity.Channel, params *entity.FeedParams) ([]byte, error)
+	GeneratePost(channel *entity.Channel, params *entity.PostParams) ([]byte, error)
 }
 
+// negativeCacheTTL limits how long missing channels and posts are remembered
+const negativeCacheTTL = 5 * time.Minute
+
+// defaultRetryAfter is suggested to clients when t.me didn't provide a delay
+const defaultRetryAfter = time.Minute
+
+const (
+	// scrapeLockTTL limits how long an instance may hold the scrape lock of a channel
+	scrapeLockTTL = 2 * time.Minute
+
+	// scrapeLockWaitMax limits how long other instances wait for the lock before scraping
+	// without it, leaving them time to respond within the server's write timeout
+	scrapeLockWaitMax = 20 * time.Second
+
+	// scrapeLockPollInterval is how often instances waiting for the scrape lock check the cache
+	scrapeLockPollInterval = 500 * time.Millisecond
+)
+
+const (
+	// staleWhileRevalidateDefault is how long an expired channel is served while it's refreshed
+	staleWhileRevalidateDefault = 10 * time.Minute
+
+	// staleIfErrorDefault is how long an expired channel is served when t.me fails
+	staleIfErrorDefault = 24 * time.Hour
+
+	// refreshBackoff is how long a channel isn't refreshed again after a failed background refresh
+	refreshBackoff = time.Minute
+)
+
 // telegramHandler handles routes for Telegram feeds
 type telegramHandler struct {
 	cache     cache.Cache
 	scraper   Scraper
 	generator Generator
 	logger    *slog.Logger
+	// Coalesces concurrent scrapes of the same channel
+	scrapes singleflight.Group
+	// Coalesces scrapes across instances sharing the cache, nil if disabled
+	locker cache.Locker
+	// How long past the cache TTL channels are served while refreshed in the background
+	staleWhileRevalidate time.Duration
+	// How long past the cache TTL channels are served when a scrape fails
+	staleIfError time.Duration
+	// Tracks scrapes outliving their requests, which the server waits for before closing the cache
+	background *sync.WaitGroup
 }
 
-// NewTelegramHandler registers all Telegram-related handlers
+// NewTelegramHandler registers all Telegram-related handlers,
+// background tracks the scrapes going on after their requests are served
 func NewTelegramHandler(
 	mux *http.ServeMux,
 	c cache.Cache, s Scraper, g Generator,
+	background *sync.WaitGroup,
 ) {
 	handler := &telegramHandler{
-		cache:     c,
-		scraper:   s,
-		generator: g,
-		logger:    app.Logger(),
+		cache:                c,
+		scraper:              s,
+		generator:            g,
+		logger:               app.Logger(),
+		locker:               newScrapeLockerFromEnv(c),
+		staleWhileRevalidate: durationFromEnv("STALE_WHILE_REVALIDATE", staleWhileRevalidateDefault),
+		staleIfError:         durationFromEnv("STALE_IF_ERROR", staleIfErrorDefault),
+		background:           background,
 	}
 
 	mux.HandleFunc("GET /telegram/channel/{username}", handler.getChannelFeed)
+	mux.HandleFunc("GET /telegram/channel/{username}/info", handler.getChannelInfo)
+	mux.HandleFunc("GET /telegram/channel/{username}/{postID}", handler.getPost)
+}
+
+// channelInfo is the JSON representation of channel metadata
+type channelInfo struct {
+	Username    string `json:"username"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	ImageURL    string `json:"image_url"`
+	Verified    bool   `json:"verified"`
+	Subscribers int    `json:"subscribers"`
+	Photos      int    `json:"photos"`
+	Videos      int    `json:"videos"`
+	Links       int    `json:"links"`
+	Files       int    `json:"files"`
 }
 
 // getChannelFeed handles requests for Telegram channel feeds
@@ -56,91 +126,614 @@ func (h *telegramHandler) getChannelFeed(w http.ResponseWriter, r *http.Request)
 
 	// Try to get from cache first if caching is enabled
 	if params.CacheTTL > 0 {
-		cacheKey := h.buildCacheKey(params)
+		if feed := h.getCachedFeed(r.Context(), h.buildCacheKey(params)); feed != nil {
+			// Feeds cached by requests with a longer cache_ttl may expire later
+			maxAge := min(time.Until(feed.ExpiresAt), time.Duration(params.CacheTTL)*time.Minute)
+
+			w.Header().Set("X-CACHE-STATUS", "HIT")
+			h.serveContent(w, feed.Content, params.Format, maxAge)
+			return
+		}
+
+		if err := h.getCachedError(r.Context(), channelErrorKey(params.Username)); err != nil {
+			w.Header().Set("X-CACHE-STATUS", "HIT")
+			h.handleError(w, err, http.StatusNotFound)
+			return
+		}
+	}
+
+	// Feeds in all formats and with all filters are made of the same scraped channel
+	cached, status, err := h.getChannel(r.Context(), params.Username, params.ScrapeParams(), params.CacheTTL)
+
+	if err != nil {
+		h.cacheError(channelErrorKey(params.Username), err, params.CacheTTL)
+		h.handleError(w, err, scrapeErrorStatus(err))
+		return
+	}
+
+	// Generate feed
+	content, err := h.generator.Generate(cached.Channel, params)
+
+	if err != nil {
+		h.handleError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Cache the rendered feed too, so polling the same URL skips the generation.
+	// It expires with the channel it's made of, so stale feeds are not cached
+	// here or by clients, which come back for the refreshed channel
+	expiresAt := cached.ScrapedAt.Add(time.Duration(params.CacheTTL) * time.Minute)
+	maxAge := time.Until(expiresAt)
+
+	if params.CacheTTL > 0 && maxAge > 0 {
+		h.cacheFeed(h.buildCacheKey(params), &cachedFeed{ExpiresAt: expiresAt, Content: content}, maxAge)
+	}
+
+	w.Header().Set("X-CACHE-STATUS", status)
+	h.serveContent(w, content, params.Format, maxAge)
+}
+
+// getChannelInfo handles requests for Telegram channel metadata
+func (h *telegramHandler) getChannelInfo(w http.ResponseWriter, r *http.Request) {
+	params, err := entity.NewFeedParamFromRequest(r)
+
+	if err != nil {
+		h.handleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if params.CacheTTL > 0 {
+		if err := h.getCachedError(r.Context(), channelErrorKey(params.Username)); err != nil {
+			w.Header().Set("X-CACHE-STATUS", "HIT")
+			h.handleError(w, err, http.StatusNotFound)
+			return
+		}
+	}
+
+	// Only the first page is needed for the channel info, which is shared with default feeds
+	cached, status, err := h.getChannel(r.Context(), params.Username, entity.ScrapeParams{Depth: 1}, params.CacheTTL)
+
+	if err != nil {
+		h.cacheError(channelErrorKey(params.Username), err, params.CacheTTL)
+		h.handleError(w, err, scrapeErrorStatus(err))
+		return
+	}
+
+	channel := cached.Channel
+	content, err := json.Marshal(channelInfo{
+		Username:    channel.Username,
+		Title:       channel.Title,
+		Description: channel.Description,
+		URL:         channel.URL,
+		ImageURL:    channel.ImageURL,
+		Verified:    channel.Verified,
+		Subscribers: channel.Subscribers,
+		Photos:      channel.Photos,
+		Videos:      channel.Videos,
+		Links:       channel.Links,
+		Files:       channel.Files,
+	})
+
+	if err != nil {
+		h.handleError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Clients may keep the info for as long as the channel is cached
+	maxAge := time.Duration(params.CacheTTL)*time.Minute - time.Since(cached.ScrapedAt)
+
+	w.Header().Set("X-CACHE-STATUS", status)
+	h.writeContent(w, content, "application/json", maxAge)
+}
+
+// getPost handles requests for a single post of a Telegram channel
+func (h *telegramHandler) getPost(w http.ResponseWriter, r *http.Request) {
+	params, err := entity.NewPostParamsFromRequest(r)
+
+	if err != nil {
+		h.handleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	cacheKey := fmt.Sprintf("telegram:post:%s:%d:%s", params.Username, params.PostID, params.Format)
+
+	if params.CacheTTL > 0 {
 		cachedContent, cacheErr := h.cache.Get(r.Context(), cacheKey)
 
 		if cacheErr == nil {
-			// Cache hit
 			w.Header().Set("X-CACHE-STATUS", "HIT")
-			h.serveContent(w, cachedContent, params.Format, params.CacheTTL)
+			h.servePost(w, cachedContent, params.Format, time.Duration(params.CacheTTL)*time.Minute)
 			return
 		} else if cacheErr != cache.ErrCacheMiss {
-			// Real error, not just cache miss
 			h.logger.Error("Cache error", "error", cacheErr)
 		}
+
+		if err := h.getCachedError(r.Context(), postErrorKey(params.Username, params.PostID)); err != nil {
+			w.Header().Set("X-CACHE-STATUS", "HIT")
+			h.handleError(w, err, http.StatusNotFound)
+			return
+		}
 	}
 
-	// Cache miss or caching disabled - scrape the channel
-	channel, err := h.scraper.Scrape(r.Context(), params.Username)
+	channel, err := h.scraper.ScrapePost(r.Context(), params.Username, params.PostID)
 
 	if err != nil {
-		h.handleError(w, err, http.StatusInternalServerError)
+		h.cacheError(postErrorKey(params.Username, params.PostID), err, params.CacheTTL)
+		h.handleError(w, err, scrapeErrorStatus(err))
 		return
 	}
 
-	// Generate feed
-	content, err := h.generator.Generate(channel, params)
+	content, err := h.generator.GeneratePost(channel, params)
 
 	if err != nil {
 		h.handleError(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	// Cache the result if caching is enabled
 	if params.CacheTTL > 0 {
-		cacheKey := h.buildCacheKey(params)
-		cacheTTL := time.Duration(params.CacheTTL) * time.Minute
-
-		// Use background context for caching to avoid cancellation
 		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if err := h.cache.Set(cacheCtx, cacheKey, content, cacheTTL); err != nil {
+		if err := h.cache.Set(cacheCtx, cacheKey, content, time.Duration(params.CacheTTL)*time.Minute); err != nil {
 			h.logger.Error("Failed to cache content", "error", err)
 		}
 	}
 
 	w.Header().Set("X-CACHE-STATUS", "MISS")
-	h.serveContent(w, content, params.Format, params.CacheTTL)
+	h.servePost(w, content, params.Format, time.Duration(params.CacheTTL)*time.Minute)
 }
 
-// buildCacheKey generates a cache key based on request parameters
-func (h *telegramHandler) buildCacheKey(params *entity.FeedParams) string {
-	excludeWords := ""
+// cachedFeed is a rendered feed stored in the cache
+type cachedFeed struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Content   []byte    `json:"content"`
+}
+
+// cachedChannel is a scraped channel stored in the cache
+type cachedChannel struct {
+	ScrapedAt time.Time       `json:"scraped_at"`
+	Channel   *entity.Channel `json:"channel"`
+}
+
+// getChannel returns the channel from the cache if it was scraped within cacheTTL minutes,
+// otherwise scrapes it and caches it for all requests with the same scrape params.
+// The channel comes with the time it was scraped at. The returned status is HIT, MISS or STALE for a channel cached for longer than cacheTTL,
+// which is served while it's refreshed in the background or when the scrape fails
+func (h *telegramHandler) getChannel(
+	ctx context.Context, username string, params entity.ScrapeParams, cacheTTL int,
+) (*cachedChannel, string, error) {
+	key := channelDataKey(username, params)
+	ttl := time.Duration(cacheTTL) * time.Minute
+
+	var stale *cachedChannel
 
-	if len(params.ExcludeWords) > 0 {
-		excludeWords = strings.Join(params.ExcludeWords, "|")
+	if cacheTTL > 0 {
+		if cached := h.getCachedChannel(ctx, key); cached != nil {
+			// Entries cached by requests with a longer cache_ttl may be too old for this one
+			age := time.Since(cached.ScrapedAt)
+
+			switch {
+			case age < ttl:
+				return cached, "HIT", nil
+			case age < ttl+h.staleWhileRevalidate:
+				h.refreshChannel(ctx, key, username, params, ttl)
+				return cached, "STALE", nil
+			case age < ttl+h.staleIfError:
+				stale = cached
+			}
+		}
 	}
 
-	caseSensitive := "0"
+	channel, cached, err := h.scrapeCoalesced(ctx, key, username, params, ttl)
 
-	if params.ExcludeCaseSensitive {
-		caseSensitive = "1"
+	if err != nil {
+		// Missing channels aren't served from the cache, as they won't come back
+		if stale != nil && ctx.Err() == nil && scrapeErrorStatus(err) != http.StatusNotFound {
+			h.logger.Warn("Serving stale channel", "key", key, "error", err)
+			return stale, "STALE", nil
+		}
+
+		return nil, "", err
 	}
 
-	return fmt.Sprintf("telegram:channel:%s:%s:%s:%s",
+	if cached {
+		return channel, "HIT", nil
+	}
+
+	return channel, "MISS", nil
+}
+
+// scrapeCoalesced scrapes the channel once for all concurrent requests with the same scrape params.
+// The scrape goes on even if the client that started it goes away
+func (h *telegramHandler) scrapeCoalesced(
+	ctx context.Context, key, username string, params entity.ScrapeParams, ttl time.Duration,
+) (*cachedChannel, bool, error) {
+	h.background.Add(1)
+
+	result := h.scrapes.DoChan(key, func() (any, error) {
+		return h.scrapeChannel(context.WithoutCancel(ctx), key, username, params, ttl)
+	})
+
+	select {
+	case <-ctx.Done():
+		// The scrape is tracked until it's done, as it caches the channel
+		go func() {
+			<-result
+			h.background.Done()
+		}()
+
+		return nil, false, ctx.Err()
+	case res := <-result:
+		h.background.Done()
+
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+
+		scraped := res.Val.(*scrapedChannel)
+
+		return scraped.channel, scraped.cached, nil
+	}
+}
+
+// refreshChannel scrapes the channel in the background, joining a scrape in progress if any
+func (h *telegramHandler) refreshChannel(
+	ctx context.Context, key, username string, params entity.ScrapeParams, ttl time.Duration,
+) {
+	// While t.me is failing, requests don't start a refresh each
+	if _, err := h.cache.Get(ctx, refreshErrorKey(key)); err == nil {
+		return
+	}
+
+	h.background.Add(1)
+
+	go func() {
+		defer h.background.Done()
+
+		if _, _, err := h.scrapeCoalesced(context.WithoutCancel(ctx), key, username, params, ttl); err != nil {
+			h.logger.Error("Failed to refresh channel", "key", key, "error", err)
+
+			cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := h.cache.Set(cacheCtx, refreshErrorKey(key), []byte(err.Error()), refreshBackoff); err != nil {
+				h.logger.Error("Failed to cache refresh error", "error", err)
+			}
+		}
+	}()
+}
+
+// scrapedChannel is the result of a coalesced scrape
+type scrapedChannel struct {
+	channel *cachedChannel
+	// Whether another instance has scraped the channel in the meantime
+	cached bool
+}
+
+// scrapeChannel scrapes the channel and caches it for ttl. With the distributed lock enabled,
+// it waits for another instance scraping the same channel and takes its result from the cache
+func (h *telegramHandler) scrapeChannel(
+	ctx context.Context, key, username string, params entity.ScrapeParams, ttl time.Duration,
+) (*scrapedChannel, error) {
+	if h.locker != nil && ttl > 0 {
+		unlock, channel := h.waitScrapeLock(ctx, key, ttl)
+
+		if channel != nil {
+			return &scrapedChannel{channel: channel, cached: true}, nil
+		}
+
+		if unlock != nil {
+			defer unlock()
+		}
+	}
+
+	channel, err := h.scraper.Scrape(ctx, username, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scraped := &cachedChannel{ScrapedAt: time.Now(), Channel: channel}
+
+	if ttl > 0 {
+		// Kept past the TTL to be served stale
+		staleTTL := ttl + max(h.staleWhileRevalidate, h.staleIfError)
+		h.cacheChannel(key, scraped, staleTTL)
+	}
+
+	return &scrapedChannel{channel: scraped}, nil
+}
+
+// waitScrapeLock acquires the scrape lock of the channel, or returns the channel
+// once the instance holding the lock has cached it. The lock isn't required to scrape,
+// so errors and timeouts return neither, leaving the caller to scrape without it
+func (h *telegramHandler) waitScrapeLock(
+	ctx context.Context, key string, ttl time.Duration,
+) (func(), *cachedChannel) {
+	timeout := time.NewTimer(scrapeLockWaitMax)
+	defer timeout.Stop()
+
+	for {
+		unlock, ok, err := h.locker.TryLock(ctx, scrapeLockKey(key), scrapeLockTTL)
+
+		if err != nil {
+			h.logger.Error("Failed to acquire scrape lock", "key", key, "error", err)
+			return nil, nil
+		}
+
+		if ok {
+			// The channel might have been cached right before the lock was released
+			if channel := h.getFreshChannel(ctx, key, ttl); channel != nil {
+				unlock()
+				return nil, channel
+			}
+
+			return unlock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-timeout.C:
+			h.logger.Warn("Timed out waiting for scrape lock", "key", key)
+			return nil, nil
+		case <-time.After(scrapeLockPollInterval):
+		}
+
+		if channel := h.getFreshChannel(ctx, key, ttl); channel != nil {
+			return nil, channel
+		}
+	}
+}
+
+// getFreshChannel returns the channel cached under the key if it was scraped within ttl
+func (h *telegramHandler) getFreshChannel(ctx context.Context, key string, ttl time.Duration) *cachedChannel {
+	if cached := h.getCachedChannel(ctx, key); cached != nil && time.Since(cached.ScrapedAt) < ttl {
+		return cached
+	}
+
+	return nil
+}
+
+// getCachedChannel returns the channel cached under the key, if any
+func (h *telegramHandler) getCachedChannel(ctx context.Context, key string) *cachedChannel {
+	data, err := h.cache.Get(ctx, key)
+
+	if err != nil {
+		if err != cache.ErrCacheMiss {
+			h.logger.Error("Cache error", "error", err)
+		}
+
+		return nil
+	}
+
+	var cached cachedChannel
+
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Channel == nil {
+		h.logger.Error("Failed to decode cached channel", "key", key, "error", err)
+		return nil
+	}
+
+	return &cached
+}
+
+// getCachedFeed returns the feed cached under the key, if any
+func (h *telegramHandler) getCachedFeed(ctx context.Context, key string) *cachedFeed {
+	data, err := h.cache.Get(ctx, key)
+
+	if err != nil {
+		if err != cache.ErrCacheMiss {
+			h.logger.Error("Cache error", "error", err)
+		}
+
+		return nil
+	}
+
+	var feed cachedFeed
+
+	if err := json.Unmarshal(data, &feed); err != nil || feed.Content == nil {
+		h.logger.Error("Failed to decode cached feed", "key", key, "error", err)
+		return nil
+	}
+
+	return &feed
+}
+
+// cacheFeed stores a rendered feed under the key
+func (h *telegramHandler) cacheFeed(key string, feed *cachedFeed, ttl time.Duration) {
+	data, err := json.Marshal(feed)
+
+	if err != nil {
+		h.logger.Error("Failed to encode feed", "error", err)
+		return
+	}
+
+	// Use background context for caching to avoid cancellation
+	cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.cache.Set(cacheCtx, key, data, ttl); err != nil {
+		h.logger.Error("Failed to cache content", "error", err)
+	}
+}
+
+// cacheChannel stores a scraped channel under the key
+func (h *telegramHandler) cacheChannel(key string, cached *cachedChannel, ttl time.Duration) {
+	data, err := json.Marshal(cached)
+
+	if err != nil {
+		h.logger.Error("Failed to encode channel", "error", err)
+		return
+	}
+
+	cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.cache.Set(cacheCtx, key, data, ttl); err != nil {
+		h.logger.Error("Failed to cache channel", "error", err)
+	}
+}
+
+// buildCacheKey generates a cache key based on request parameters
+func (h *telegramHandler) buildCacheKey(params *entity.FeedParams) string {
+	return fmt.Sprintf("telegram:channel:%s:%s:%s:%s:%s:%s:%s:%s:%d:%d:%d:%s:%s:%s:%d:%s:%s:%s:%s:%s",
 		params.Username,
 		params.Format,
-		excludeWords,
-		caseSensitive)
+		wordsKey(params.ExcludeWords),
+		boolKey(params.ExcludeCaseSensitive),
+		wordsKey(params.IncludeWords),
+		boolKey(params.IncludeCaseSensitive),
+		patternsKey(params.ExcludePatterns),
+		patternsKey(params.IncludePatterns),
+		params.Depth,
+		params.Limit,
+		params.MaxAge,
+		boolKey(params.AttachmentsOnly),
+		boolKey(params.ExcludeForwards),
+		boolKey(params.ExcludeAds),
+		params.MinViews,
+		boolKey(params.ShowStats),
+		wordsKey(params.Authors),
+		wordsKey(params.Tags),
+		url.QueryEscape(params.Query),
+		url.QueryEscape(params.Filter.String()))
+}
+
+// newScrapeLockerFromEnv returns the cache as a distributed lock if SCRAPE_LOCK is enabled
+// and the cache supports locking
+func newScrapeLockerFromEnv(c cache.Cache) cache.Locker {
+	enabled, _ := strconv.ParseBool(os.Getenv("SCRAPE_LOCK"))
+
+	if !enabled {
+		return nil
+	}
+
+	locker, ok := c.(cache.Locker)
+
+	if !ok {
+		app.Logger().Warn("SCRAPE_LOCK is ignored, the cache backend doesn't support locking")
+		return nil
+	}
+
+	return locker
+}
+
+// durationFromEnv reads a duration set in minutes with the environment variable,
+// 0 disables the feature
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	v := os.Getenv(name)
+
+	if v == "" {
+		return defaultValue
+	}
+
+	minutes, err := strconv.Atoi(v)
+
+	if err != nil || minutes < 0 {
+		app.Logger().Warn("Invalid duration, using the default one", "name", name, "value", v, "default", defaultValue)
+		return defaultValue
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// scrapeLockKey is the key of the distributed lock of a channel scrape
+func scrapeLockKey(channelKey string) string {
+	return strings.Replace(channelKey, "telegram:channel-data:", "telegram:scrape-lock:", 1)
+}
+
+// refreshErrorKey marks a recently failed background refresh of a channel
+func refreshErrorKey(channelKey string) string {
+	return strings.Replace(channelKey, "telegram:channel-data:", "telegram:refresh-error:", 1)
+}
+
+// channelDataKey is the cache key of a scraped channel
+func channelDataKey(username string, params entity.ScrapeParams) string {
+	return fmt.Sprintf("telegram:channel-data:%s:%d:%d:%d:%s",
+		username,
+		params.Depth,
+		params.Limit,
+		int(params.MaxAge/time.Hour),
+		url.QueryEscape(params.Query))
+}
+
+// channelErrorKey is the cache key of a missing or private channel
+func channelErrorKey(username string) string {
+	return fmt.Sprintf("telegram:channel-error:%s", username)
+}
+
+// postErrorKey is the cache key of a missing post
+func postErrorKey(username string, postID int) string {
+	return fmt.Sprintf("telegram:post-error:%s:%d", username, postID)
+}
+
+// patternsKey formats patterns for a cache key, escaped since patterns may contain "|"
+func patternsKey(patterns []*entity.Pattern) string {
+	sources := make([]string, 0, len(patterns))
+
+	for _, p := range patterns {
+		sources = append(sources, p.String())
+	}
+
+	return wordsKey(sources)
+}
+
+// wordsKey escapes the separators in free-text values, so different lists get different keys
+func wordsKey(words []string) string {
+	escaped := make([]string, 0, len(words))
+
+	for _, w := range words {
+		escaped = append(escaped, url.QueryEscape(w))
+	}
+
+	return strings.Join(escaped, "|")
+}
+
+// boolKey formats a flag for a cache key
+func boolKey(flag bool) string {
+	if flag {
+		return "1"
+	}
+
+	return "0"
 }
 
 // serveContent sends the content to the client with appropriate headers
-func (h *telegramHandler) serveContent(w http.ResponseWriter, content []byte, format string, cacheTTL int) {
+func (h *telegramHandler) serveContent(w http.ResponseWriter, content []byte, format string, maxAge time.Duration) {
 	var contentType string
 	switch format {
-	case entity.FormatRSS:
+	case entity.FormatRSS, entity.FormatPodcast:
 		contentType = "application/rss+xml"
 	case entity.FormatAtom:
 		contentType = "application/atom+xml"
+	case entity.FormatJSON:
+		contentType = "application/feed+json"
+	case entity.FormatHTML:
+		contentType = "text/html"
 	default:
 		contentType = "application/xml"
 	}
 
+	h.writeContent(w, content, contentType, maxAge)
+}
+
+// servePost sends a single post, which is a plain JSON object rather than JSON Feed
+func (h *telegramHandler) servePost(w http.ResponseWriter, content []byte, format string, maxAge time.Duration) {
+	if format == entity.FormatJSON {
+		h.writeContent(w, content, "application/json", maxAge)
+		return
+	}
+
+	h.serveContent(w, content, format, maxAge)
+}
+
+// writeContent sends the content with the given type and cache headers,
+// letting clients keep it for maxAge, which matches how long it's cached here
+func (h *telegramHandler) writeContent(w http.ResponseWriter, content []byte, contentType string, maxAge time.Duration) {
 	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
 
-	if cacheTTL > 0 {
-		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", cacheTTL*60))
+	if seconds := int(maxAge.Round(time.Second).Seconds()); seconds > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
 	} else {
 		w.Header().Set("Cache-Control", "no-cache")
 	}
@@ -152,10 +745,86 @@ func (h *telegramHandler) serveContent(w http.ResponseWriter, content []byte, fo
 	}
 }
 
+// cachedError is a not found result restored from the cache
+type cachedError struct {
+	message string
+}
+
+func (e *cachedError) Error() string {
+	return e.message
+}
+
+func (e *cachedError) Is(target error) bool {
+	return target == entity.ErrNotFound
+}
+
+// getCachedError returns a not found error cached for the key, if any
+func (h *telegramHandler) getCachedError(ctx context.Context, key string) error {
+	message, err := h.cache.Get(ctx, key)
+
+	if err != nil {
+		if err != cache.ErrCacheMiss {
+			h.logger.Error("Cache error", "error", err)
+		}
+
+		return nil
+	}
+
+	return &cachedError{message: string(message)}
+}
+
+// cacheError remembers a missing channel or post for a short time,
+// so that a typo in a feed URL doesn't hit t.me on every poll
+func (h *telegramHandler) cacheError(key string, err error, cacheTTL int) {
+	if cacheTTL == 0 || scrapeErrorStatus(err) != http.StatusNotFound {
+		return
+	}
+
+	ttl := min(negativeCacheTTL, time.Duration(cacheTTL)*time.Minute)
+
+	cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.cache.Set(cacheCtx, key, []byte(err.Error()), ttl); err != nil {
+		h.logger.Error("Failed to cache error", "error", err)
+	}
+}
+
+// scrapeErrorStatus maps a scraper error to an HTTP status code
+func scrapeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrNotFound), errors.Is(err, entity.ErrPrivate):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, entity.ErrUpstream):
+		return http.StatusBadGateway
+	case errors.Is(err, entity.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// retryAfter returns the delay suggested by t.me or the default one
+func retryAfter(err error) time.Duration {
+	var retryErr *entity.RetryableError
+
+	if errors.As(err, &retryErr) && retryErr.RetryAfter > 0 {
+		return retryErr.RetryAfter
+	}
+
+	return defaultRetryAfter
+}
+
 // handleError responds with an error message
 func (h *telegramHandler) handleError(w http.ResponseWriter, err error, statusCode int) {
 	h.logger.Error("Request error", "error", err, "status", statusCode)
 
+	if statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter(err).Seconds())))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
@@ -2,10 +2,16 @@ package rest_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,22 +24,32 @@ import (
 
 // MockScraper is a mock implementation of the Scraper interface
 type MockScraper struct {
-	ScrapeFunc func(ctx context.Context, username string) (*entity.Channel, error)
+	ScrapeFunc     func(ctx context.Context, username string, params entity.ScrapeParams) (*entity.Channel, error)
+	ScrapePostFunc func(ctx context.Context, username string, postID int) (*entity.Channel, error)
 }
 
-func (m *MockScraper) Scrape(ctx context.Context, username string) (*entity.Channel, error) {
-	return m.ScrapeFunc(ctx, username)
+func (m *MockScraper) Scrape(ctx context.Context, username string, params entity.ScrapeParams) (*entity.Channel, error) {
+	return m.ScrapeFunc(ctx, username, params)
+}
+
+func (m *MockScraper) ScrapePost(ctx context.Context, username string, postID int) (*entity.Channel, error) {
+	return m.ScrapePostFunc(ctx, username, postID)
 }
 
 // MockGenerator is a mock implementation of the Generator interface
 type MockGenerator struct {
-	GenerateFunc func(channel *entity.Channel, params *entity.FeedParams) ([]byte, error)
+	GenerateFunc     func(channel *entity.Channel, params *entity.FeedParams) ([]byte, error)
+	GeneratePostFunc func(channel *entity.Channel, params *entity.PostParams) ([]byte, error)
 }
 
 func (m *MockGenerator) Generate(channel *entity.Channel, params *entity.FeedParams) ([]byte, error) {
 	return m.GenerateFunc(channel, params)
 }
 
+func (m *MockGenerator) GeneratePost(channel *entity.Channel, params *entity.PostParams) ([]byte, error) {
+	return m.GeneratePostFunc(channel, params)
+}
+
 // MockCache is a mock implementation of the Cache interface
 type MockCache struct {
 	GetFunc func(ctx context.Context, key string) ([]byte, error)
@@ -52,6 +68,16 @@ func (m *MockCache) Close() error {
 	return nil
 }
 
+// MockLockingCache is a mock implementation of the Cache and Locker interfaces
+type MockLockingCache struct {
+	MockCache
+	TryLockFunc func(ctx context.Context, key string, ttl time.Duration) (func(), bool, error)
+}
+
+func (m *MockLockingCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	return m.TryLockFunc(ctx, key, ttl)
+}
+
 func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -71,7 +97,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 				}
 
 				// Scraper returns channel data
-				mockScraper.ScrapeFunc = func(_ context.Context, username string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					assert.Equal(t, "testchannel", username)
 					return &entity.Channel{
 						Username: "testchannel",
@@ -119,7 +145,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 				}
 
 				// Scraper returns channel data
-				mockScraper.ScrapeFunc = func(_ context.Context, username string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					assert.Equal(t, "testchannel", username)
 					return &entity.Channel{
 						Username: "testchannel",
@@ -153,13 +179,20 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 			name: "Cache hit",
 			url:  "/telegram/channel/testchannel?format=rss&cache_ttl=60",
 			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
-				// Cache hit
-				mockCache.GetFunc = func(_ context.Context, _ string) ([]byte, error) {
-					return []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss version=\"2.0\"><channel><title>Cached Feed</title></channel></rss>"), nil
+				// Cache hit of a feed rendered half an hour ago
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					if strings.HasPrefix(key, "telegram:channel:testchannel:rss:") {
+						return json.Marshal(map[string]any{
+							"expires_at": time.Now().Add(30 * time.Minute),
+							"content":    []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss version=\"2.0\"><channel><title>Cached Feed</title></channel></rss>"),
+						})
+					}
+
+					return nil, cache.ErrCacheMiss
 				}
 
 				// Scraper and generator should not be called
-				mockScraper.ScrapeFunc = func(_ context.Context, _ string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					t.Fatal("Scraper should not be called on cache hit")
 					return nil, nil
 				}
@@ -172,11 +205,93 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			expectedHeaders: map[string]string{
 				"Content-Type":   "application/rss+xml; charset=utf-8",
-				"Cache-Control":  "public, max-age=3600",
+				"Cache-Control":  "public, max-age=1800",
 				"X-CACHE-STATUS": "HIT",
 			},
 			expectedBodyPart: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss",
 		},
+		{
+			name: "Cached channel is rendered with the request params",
+			url:  "/telegram/channel/testchannel?format=atom&exclude=word",
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					if key == "telegram:channel-data:testchannel:1:0:0:" {
+						return []byte(fmt.Sprintf(
+							`{"scraped_at": %q, "channel": {"Username": "testchannel", "Title": "Cached Channel"}}`,
+							time.Now().Add(-time.Minute).Format(time.RFC3339Nano),
+						)), nil
+					}
+
+					return nil, cache.ErrCacheMiss
+				}
+
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					t.Fatal("Scraper should not be called for a cached channel")
+					return nil, nil
+				}
+
+				mockGenerator.GenerateFunc = func(channel *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, "Cached Channel", channel.Title)
+					assert.Equal(t, entity.FormatAtom, params.Format)
+					assert.Equal(t, []string{"word"}, params.ExcludeWords)
+					return []byte("<feed></feed>"), nil
+				}
+
+				mockCache.SetFunc = func(_ context.Context, key string, _ []byte, ttl time.Duration) error {
+					assert.Equal(t, "telegram:channel:testchannel:atom:word:0::0:::1:0:0:0:0:0:0:0::::", key)
+					// Expires with the channel scraped a minute ago
+					assert.InDelta(t, 59*time.Minute, ttl, float64(time.Second))
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type":   "application/atom+xml; charset=utf-8",
+				"Cache-Control":  "public, max-age=3540",
+				"X-CACHE-STATUS": "HIT",
+			},
+			expectedBodyPart: "<feed>",
+		},
+		{
+			name: "Cached channel older than cache_ttl is scraped again",
+			url:  "/telegram/channel/testchannel?cache_ttl=10",
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					if key == "telegram:channel-data:testchannel:1:0:0:" {
+						return []byte(fmt.Sprintf(
+							`{"scraped_at": %q, "channel": {"Username": "testchannel", "Title": "Cached Channel"}}`,
+							time.Now().Add(-time.Hour).Format(time.RFC3339),
+						)), nil
+					}
+
+					return nil, cache.ErrCacheMiss
+				}
+
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return &entity.Channel{Username: "testchannel", Title: "Fresh Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(channel *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, "Fresh Channel", channel.Title)
+					return []byte("<rss></rss>"), nil
+				}
+
+				mockCache.SetFunc = func(_ context.Context, key string, _ []byte, ttl time.Duration) error {
+					if key == "telegram:channel-data:testchannel:1:0:0:" {
+						// Kept for a day more to be served if t.me fails
+						assert.Equal(t, 10*time.Minute+24*time.Hour, ttl)
+					} else {
+						assert.InDelta(t, 10*time.Minute, ttl, float64(time.Second))
+					}
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-CACHE-STATUS": "MISS",
+			},
+			expectedBodyPart: "<rss>",
+		},
 		{
 			name: "Scraper error",
 			url:  "/telegram/channel/testchannel",
@@ -187,7 +302,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 				}
 
 				// Scraper returns error
-				mockScraper.ScrapeFunc = func(_ context.Context, _ string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					return nil, errors.New("scraper error")
 				}
 
@@ -213,7 +328,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 				}
 
 				// Scraper returns channel data
-				mockScraper.ScrapeFunc = func(_ context.Context, _ string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					return &entity.Channel{
 						Username: "testchannel",
 						Title:    "Test Channel",
@@ -222,6 +337,12 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 					}, nil
 				}
 
+				// The scraped channel is cached anyway
+				mockCache.SetFunc = func(_ context.Context, key string, _ []byte, _ time.Duration) error {
+					assert.Equal(t, "telegram:channel-data:testchannel:1:0:0:", key)
+					return nil
+				}
+
 				// Generator returns error
 				mockGenerator.GenerateFunc = func(_ *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
 					return nil, errors.New("generator error")
@@ -244,7 +365,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 				}
 
 				// Scraper returns channel data
-				mockScraper.ScrapeFunc = func(_ context.Context, _ string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					return &entity.Channel{
 						Username: "testchannel",
 						Title:    "Test Channel",
@@ -282,7 +403,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 				}
 
 				// Scraper returns channel data
-				mockScraper.ScrapeFunc = func(_ context.Context, _ string) (*entity.Channel, error) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
 					return &entity.Channel{
 						Username: "testchannel",
 						Title:    "Test Channel",
@@ -313,6 +434,335 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 			},
 			expectedBodyPart: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss",
 		},
+		{
+			name: "Feed with include words parameter",
+			url:  "/telegram/channel/testchannel?include=word1|word2&include_case_sensitive=true&exclude=word3",
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					assert.Contains(t, []string{
+						"telegram:channel:testchannel:rss:word3:0:word1|word2:1:::1:0:0:0:0:0:0:0::::",
+						"telegram:channel-data:testchannel:1:0:0:",
+						"telegram:channel-error:testchannel",
+					}, key)
+					return nil, cache.ErrCacheMiss
+				}
+
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return &entity.Channel{Username: "testchannel", Title: "Test Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, []string{"word1", "word2"}, params.IncludeWords)
+					assert.True(t, params.IncludeCaseSensitive)
+					assert.Equal(t, []string{"word3"}, params.ExcludeWords)
+					assert.False(t, params.ExcludeCaseSensitive)
+					return []byte("<rss></rss>"), nil
+				}
+
+				mockCache.SetFunc = func(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-CACHE-STATUS": "MISS",
+			},
+			expectedBodyPart: "<rss>",
+		},
+		{
+			name: "Feed with pagination parameters",
+			url:  "/telegram/channel/testchannel?depth=3&limit=50&max_age=48",
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				// Cache miss
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					assert.Contains(t, []string{
+						"telegram:channel:testchannel:rss::0::0:::3:50:48:0:0:0:0:0::::",
+						"telegram:channel-data:testchannel:3:50:48:",
+						"telegram:channel-error:testchannel",
+					}, key)
+					return nil, cache.ErrCacheMiss
+				}
+
+				// Scraper receives pagination params
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, params entity.ScrapeParams) (*entity.Channel, error) {
+					assert.Equal(t, 3, params.Depth)
+					assert.Equal(t, 50, params.Limit)
+					assert.Equal(t, 48*time.Hour, params.MaxAge)
+					return &entity.Channel{
+						Username: "testchannel",
+						Title:    "Test Channel",
+						URL:      "https://t.me/s/testchannel",
+						Posts:    []entity.Post{},
+					}, nil
+				}
+
+				// Generator returns feed content
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+					return []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss version=\"2.0\"><channel><title>Test Channel</title></channel></rss>"), nil
+				}
+
+				// Cache set
+				mockCache.SetFunc = func(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type":   "application/rss+xml; charset=utf-8",
+				"X-CACHE-STATUS": "MISS",
+			},
+			expectedBodyPart: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rss",
+		},
+		{
+			name: "JSON Feed",
+			url:  "/telegram/channel/testchannel?format=json&cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return &entity.Channel{Username: "testchannel", Title: "Test Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, entity.FormatJSON, params.Format)
+					return []byte(`{"version": "https://jsonfeed.org/version/1.1"}`), nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type": "application/feed+json; charset=utf-8",
+			},
+			expectedBodyPart: "jsonfeed.org",
+		},
+		{
+			name: "Missing channel is cached briefly",
+			url:  "/telegram/channel/nosuchchannel",
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, _ *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, _ string) ([]byte, error) {
+					return nil, cache.ErrCacheMiss
+				}
+
+				mockScraper.ScrapeFunc = func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return nil, fmt.Errorf("channel %s %w", username, entity.ErrNotFound)
+				}
+
+				mockCache.SetFunc = func(_ context.Context, key string, value []byte, ttl time.Duration) error {
+					assert.Equal(t, "telegram:channel-error:nosuchchannel", key)
+					assert.Equal(t, "channel nosuchchannel not found", string(value))
+					assert.Equal(t, 5*time.Minute, ttl)
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedHeaders: map[string]string{
+				"Content-Type": "application/json",
+			},
+			expectedBodyPart: "channel nosuchchannel not found",
+		},
+		{
+			name: "Cached missing channel",
+			url:  "/telegram/channel/nosuchchannel",
+			setupMocks: func(mockCache *MockCache, _ *MockScraper, _ *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					if key == "telegram:channel-error:nosuchchannel" {
+						return []byte("channel nosuchchannel not found"), nil
+					}
+
+					return nil, cache.ErrCacheMiss
+				}
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedHeaders: map[string]string{
+				"X-CACHE-STATUS": "HIT",
+			},
+			expectedBodyPart: "channel nosuchchannel not found",
+		},
+		{
+			name: "Private channel",
+			url:  "/telegram/channel/privatechannel?cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, _ *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return nil, fmt.Errorf("channel %s is %w or not a channel", username, entity.ErrPrivate)
+				}
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedBodyPart:   "channel privatechannel is private or not a channel",
+		},
+		{
+			name: "Rate limited by t.me",
+			url:  "/telegram/channel/testchannel?cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, _ *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return nil, &entity.RetryableError{Err: entity.ErrRateLimited, RetryAfter: 30 * time.Second}
+				}
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"Retry-After": "30",
+			},
+			expectedBodyPart: "rate limited by t.me",
+		},
+		{
+			name: "Bad response from t.me",
+			url:  "/telegram/channel/testchannel?cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, _ *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return nil, fmt.Errorf("%w: Internal Server Error", entity.ErrUpstream)
+				}
+			},
+			expectedStatusCode: http.StatusBadGateway,
+			expectedBodyPart:   "bad response from t.me",
+		},
+		{
+			name: "t.me is unreachable",
+			url:  "/telegram/channel/testchannel?cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, _ *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return nil, fmt.Errorf("%w: connection refused", entity.ErrUnavailable)
+				}
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedHeaders: map[string]string{
+				"Retry-After": "60",
+			},
+			expectedBodyPart: "t.me is unavailable",
+		},
+		{
+			name: "Single hashtag feed is searched at t.me",
+			url:  "/telegram/channel/testchannel?tag=%23news&cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, params entity.ScrapeParams) (*entity.Channel, error) {
+					assert.Equal(t, "#news", params.Query)
+					return &entity.Channel{Username: "testchannel", Title: "Test Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, []string{"news"}, params.Tags)
+					return []byte("<rss></rss>"), nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyPart:   "<rss>",
+		},
+		{
+			name: "Search feed",
+			url:  "/telegram/channel/testchannel?q=" + url.QueryEscape("release notes") + "&tag=go&depth=5",
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					assert.Contains(t, []string{
+						"telegram:channel:testchannel:rss::0::0:::5:0:0:0:0:0:0:0::go:release+notes:",
+						"telegram:channel-data:testchannel:5:0:0:release+notes",
+						"telegram:channel-error:testchannel",
+					}, key)
+					return nil, cache.ErrCacheMiss
+				}
+
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, params entity.ScrapeParams) (*entity.Channel, error) {
+					assert.Equal(t, "release notes", params.Query)
+					assert.Equal(t, 5, params.Depth)
+					return &entity.Channel{Username: "testchannel", Title: "Test Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, "release notes", params.Query)
+					assert.Equal(t, []string{"go"}, params.Tags)
+					return []byte("<rss></rss>"), nil
+				}
+
+				mockCache.SetFunc = func(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyPart:   "<rss>",
+		},
+		{
+			name: "Too long search query",
+			url:  "/telegram/channel/testchannel?q=" + strings.Repeat("a", entity.QueryMaxLength+1),
+			setupMocks: func(_ *MockCache, _ *MockScraper, _ *MockGenerator) {
+				// No cache, scraper, or generator calls needed
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBodyPart:   "q must be at most 256 characters long",
+		},
+		{
+			name: "Several hashtags are picked from the latest posts",
+			url:  "/telegram/channel/testchannel?tag=news|go&cache_ttl=0",
+			setupMocks: func(_ *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, params entity.ScrapeParams) (*entity.Channel, error) {
+					assert.Empty(t, params.Query)
+					return &entity.Channel{Username: "testchannel", Title: "Test Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Equal(t, []string{"news", "go"}, params.Tags)
+					return []byte("<rss></rss>"), nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyPart:   "<rss>",
+		},
+		{
+			name: "Invalid filter",
+			url:  "/telegram/channel/testchannel?filter=" + url.QueryEscape(`bitcoin AND (ethereum`),
+			setupMocks: func(_ *MockCache, _ *MockScraper, _ *MockGenerator) {
+				// No cache, scraper, or generator calls needed
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedHeaders: map[string]string{
+				"Content-Type": "application/json",
+			},
+			expectedBodyPart: "filter: unclosed parenthesis at position 13",
+		},
+		{
+			name: "Feed with patterns",
+			url:  "/telegram/channel/testchannel?exclude_re=" + url.QueryEscape(`(?i)\bреклам`) + "&exclude_re=erid&include_re=" + url.QueryEscape(`^a|b`),
+			setupMocks: func(mockCache *MockCache, mockScraper *MockScraper, mockGenerator *MockGenerator) {
+				mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+					assert.Contains(t, []string{
+						"telegram:channel:testchannel:rss::0::0:" + url.QueryEscape(`(?i)\bреклам`) + "|erid:" + url.QueryEscape(`^a|b`) + ":1:0:0:0:0:0:0:0::::",
+						"telegram:channel-data:testchannel:1:0:0:",
+						"telegram:channel-error:testchannel",
+					}, key)
+					return nil, cache.ErrCacheMiss
+				}
+
+				mockScraper.ScrapeFunc = func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return &entity.Channel{Username: "testchannel", Title: "Test Channel"}, nil
+				}
+
+				mockGenerator.GenerateFunc = func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+					assert.Len(t, params.ExcludePatterns, 2)
+					assert.Len(t, params.IncludePatterns, 1)
+					return []byte("<rss></rss>"), nil
+				}
+
+				mockCache.SetFunc = func(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+					return nil
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyPart:   "<rss>",
+		},
+		{
+			name: "Invalid pattern",
+			url:  "/telegram/channel/testchannel?include_re=" + url.QueryEscape(`(unclosed`),
+			setupMocks: func(_ *MockCache, _ *MockScraper, _ *MockGenerator) {
+				// No cache, scraper, or generator calls needed
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBodyPart:   "include_re: invalid pattern",
+		},
+		{
+			name: "Invalid depth",
+			url:  "/telegram/channel/testchannel?depth=100",
+			setupMocks: func(_ *MockCache, _ *MockScraper, _ *MockGenerator) {
+				// No cache, scraper, or generator calls needed
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedHeaders: map[string]string{
+				"Content-Type": "application/json",
+			},
+			expectedBodyPart: "depth must be between 1 and 10",
+		},
 		{
 			name: "Invalid request parameters",
 			url:  "/telegram/channel/testchannel?format=invalid",
@@ -323,7 +773,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 			expectedHeaders: map[string]string{
 				"Content-Type": "application/json",
 			},
-			expectedBodyPart: "format must be rss or atom",
+			expectedBodyPart: "format must be rss, atom, podcast or json",
 		},
 		{
 			name: "Invalid cache TTL",
@@ -360,7 +810,7 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 
 			// Create a new test server
 			mux := http.NewServeMux()
-			rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator)
+			rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, &sync.WaitGroup{})
 
 			// Create a test request
 			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
@@ -384,3 +834,687 @@ func TestTelegramHandler_GetChannelFeed(t *testing.T) {
 		})
 	}
 }
+
+func TestTelegramHandler_GetChannelFeedCacheKeys(t *testing.T) {
+	// Pairs of requests whose params would be joined into the same string
+	tests := []struct {
+		name string
+		urls [2]string
+	}{
+		{
+			name: "Separator in exclude words",
+			urls: [2]string{"?exclude=a:0:b", "?exclude=a&include=b:0:"},
+		},
+		{
+			name: "Separator in authors",
+			urls: [2]string{"?author=a:b", "?author=a&tag=b:"},
+		},
+		{
+			name: "Separator in the filter",
+			urls: [2]string{"?tag=a:&filter=b", "?tag=a&filter=" + url.QueryEscape(":b")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+
+			mockCache := &MockCache{
+				GetFunc: func(_ context.Context, _ string) ([]byte, error) {
+					return nil, cache.ErrCacheMiss
+				},
+				SetFunc: func(_ context.Context, key string, _ []byte, _ time.Duration) error {
+					if strings.HasPrefix(key, "telegram:channel:") {
+						keys = append(keys, key)
+					}
+					return nil
+				},
+			}
+
+			mockScraper := &MockScraper{
+				ScrapeFunc: func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+					return &entity.Channel{Username: username}, nil
+				},
+			}
+
+			mockGenerator := &MockGenerator{
+				GenerateFunc: func(_ *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+					return []byte("<rss></rss>"), nil
+				},
+			}
+
+			mux := http.NewServeMux()
+			rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, &sync.WaitGroup{})
+
+			for _, u := range tt.urls {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telegram/channel/testchannel"+u, nil))
+				require.Equal(t, http.StatusOK, rec.Code, u)
+			}
+
+			require.Len(t, keys, 2)
+			assert.NotEqual(t, keys[0], keys[1])
+		})
+	}
+}
+
+func TestTelegramHandler_GetChannelFeedMemoryCache(t *testing.T) {
+	scrapes := 0
+
+	mockScraper := &MockScraper{
+		ScrapeFunc: func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+			scrapes++
+
+			if username == "missing" {
+				return nil, entity.ErrNotFound
+			}
+
+			return &entity.Channel{Username: username, Title: "Test Channel"}, nil
+		},
+	}
+
+	mockGenerator := &MockGenerator{
+		GenerateFunc: func(_ *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+			return []byte("<rss>test feed</rss>"), nil
+		},
+	}
+
+	mux := http.NewServeMux()
+	rest.NewTelegramHandler(mux, cache.NewMemoryCache(1<<20), mockScraper, mockGenerator, &sync.WaitGroup{})
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	rec := get("/telegram/channel/testchannel")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get("X-CACHE-STATUS"))
+
+	rec = get("/telegram/channel/testchannel")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get("X-CACHE-STATUS"))
+	assert.Equal(t, "<rss>test feed</rss>", rec.Body.String())
+
+	// Other formats and filters are made of the same scraped channel
+	rec = get("/telegram/channel/testchannel?format=atom&exclude=word")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get("X-CACHE-STATUS"))
+
+	rec = get("/telegram/channel/testchannel/info")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get("X-CACHE-STATUS"))
+
+	rec = get("/telegram/channel/missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = get("/telegram/channel/missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get("X-CACHE-STATUS"))
+
+	assert.Equal(t, 2, scrapes)
+}
+
+func TestTelegramHandler_GetChannelFeedCoalescesScrapes(t *testing.T) {
+	const clients = 10
+
+	var scrapes atomic.Int32
+
+	// Every client has looked up the scraped channel in the cache
+	var lookups sync.WaitGroup
+	lookups.Add(clients)
+
+	release := make(chan struct{})
+
+	mockCache := &MockCache{
+		GetFunc: func(_ context.Context, key string) ([]byte, error) {
+			if key == "telegram:channel-data:testchannel:1:0:0:" {
+				lookups.Done()
+			}
+
+			return nil, cache.ErrCacheMiss
+		},
+		SetFunc: func(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+			return nil
+		},
+	}
+
+	mockScraper := &MockScraper{
+		ScrapeFunc: func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+			scrapes.Add(1)
+			<-release
+			return &entity.Channel{Username: username, Title: "Test Channel"}, nil
+		},
+	}
+
+	mockGenerator := &MockGenerator{
+		GenerateFunc: func(_ *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+			return []byte(params.Format), nil
+		},
+	}
+
+	mux := http.NewServeMux()
+	rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, &sync.WaitGroup{})
+
+	formats := []string{entity.FormatRSS, entity.FormatAtom}
+	recs := make([]*httptest.ResponseRecorder, clients)
+
+	var wg sync.WaitGroup
+
+	for i := range clients {
+		recs[i] = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/telegram/channel/testchannel?format="+formats[i%2], nil)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			mux.ServeHTTP(recs[i], req)
+		}()
+	}
+
+	lookups.Wait()
+	// Let the last client join the scrape
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), scrapes.Load())
+
+	for i, rec := range recs {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, formats[i%2], rec.Body.String())
+	}
+}
+
+func TestTelegramHandler_GetChannelFeedScrapeLock(t *testing.T) {
+	t.Setenv("SCRAPE_LOCK", "true")
+
+	const dataKey = "telegram:channel-data:testchannel:1:0:0:"
+
+	t.Run("Waits for the instance holding the lock", func(t *testing.T) {
+		lookups := 0
+
+		mockCache := &MockLockingCache{
+			MockCache: MockCache{
+				GetFunc: func(_ context.Context, key string) ([]byte, error) {
+					if key != dataKey {
+						return nil, cache.ErrCacheMiss
+					}
+
+					// Cached by another instance while this one is waiting
+					if lookups++; lookups < 2 {
+						return nil, cache.ErrCacheMiss
+					}
+
+					return []byte(fmt.Sprintf(
+						`{"scraped_at": %q, "channel": {"Username": "testchannel", "Title": "Test Channel"}}`,
+						time.Now().Format(time.RFC3339),
+					)), nil
+				},
+				SetFunc: func(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+					return nil
+				},
+			},
+			TryLockFunc: func(_ context.Context, key string, _ time.Duration) (func(), bool, error) {
+				assert.Equal(t, "telegram:scrape-lock:testchannel:1:0:0:", key)
+				return nil, false, nil
+			},
+		}
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				t.Fatal("Scraper should not be called while another instance holds the lock")
+				return nil, nil
+			},
+		}
+
+		mockGenerator := &MockGenerator{
+			GenerateFunc: func(channel *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+				return []byte(channel.Title), nil
+			},
+		}
+
+		mux := http.NewServeMux()
+		rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, &sync.WaitGroup{})
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telegram/channel/testchannel", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "HIT", rec.Header().Get("X-CACHE-STATUS"))
+		assert.Equal(t, "Test Channel", rec.Body.String())
+	})
+
+	t.Run("Scrapes holding the lock", func(t *testing.T) {
+		unlocked := false
+
+		mockCache := &MockLockingCache{
+			MockCache: MockCache{
+				GetFunc: func(_ context.Context, _ string) ([]byte, error) {
+					return nil, cache.ErrCacheMiss
+				},
+				SetFunc: func(_ context.Context, key string, _ []byte, _ time.Duration) error {
+					if key == dataKey {
+						assert.False(t, unlocked, "The channel should be cached before the lock is released")
+					}
+
+					return nil
+				},
+			},
+			TryLockFunc: func(_ context.Context, _ string, ttl time.Duration) (func(), bool, error) {
+				assert.Equal(t, 2*time.Minute, ttl)
+				return func() { unlocked = true }, true, nil
+			},
+		}
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				return &entity.Channel{Username: username, Title: "Test Channel"}, nil
+			},
+		}
+
+		mockGenerator := &MockGenerator{
+			GenerateFunc: func(channel *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+				return []byte(channel.Title), nil
+			},
+		}
+
+		mux := http.NewServeMux()
+		rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, &sync.WaitGroup{})
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telegram/channel/testchannel", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "MISS", rec.Header().Get("X-CACHE-STATUS"))
+		assert.True(t, unlocked)
+	})
+}
+
+func TestTelegramHandler_GetChannelFeedStale(t *testing.T) {
+	const dataKey = "telegram:channel-data:testchannel:1:0:0:"
+
+	// A channel cached with cache_ttl=10 that many minutes ago
+	cachedChannelAged := func(minutes int) []byte {
+		return []byte(fmt.Sprintf(
+			`{"scraped_at": %q, "channel": {"Username": "testchannel", "Title": "Stale Channel"}}`,
+			time.Now().Add(-time.Duration(minutes)*time.Minute).Format(time.RFC3339),
+		))
+	}
+
+	newMux := func(mockCache cache.Cache, mockScraper *MockScraper, background *sync.WaitGroup) *http.ServeMux {
+		mockGenerator := &MockGenerator{
+			GenerateFunc: func(channel *entity.Channel, _ *entity.FeedParams) ([]byte, error) {
+				return []byte(channel.Title), nil
+			},
+		}
+
+		mux := http.NewServeMux()
+		rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, background)
+
+		return mux
+	}
+
+	get := func(mux *http.ServeMux) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telegram/channel/testchannel?cache_ttl=10", nil))
+		return rec
+	}
+
+	t.Run("Served while revalidating", func(t *testing.T) {
+		refreshed := make(chan time.Duration, 1)
+
+		mockCache := &MockCache{
+			GetFunc: func(_ context.Context, key string) ([]byte, error) {
+				if key == dataKey {
+					return cachedChannelAged(15), nil
+				}
+
+				return nil, cache.ErrCacheMiss
+			},
+			SetFunc: func(_ context.Context, key string, _ []byte, ttl time.Duration) error {
+				// The stale feed itself is not cached
+				assert.Equal(t, dataKey, key)
+				refreshed <- ttl
+				return nil
+			},
+		}
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, username string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				return &entity.Channel{Username: username, Title: "Fresh Channel"}, nil
+			},
+		}
+
+		var background sync.WaitGroup
+
+		rec := get(newMux(mockCache, mockScraper, &background))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "STALE", rec.Header().Get("X-CACHE-STATUS"))
+		assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "Stale Channel", rec.Body.String())
+
+		// The refresh is tracked until the channel is cached
+		background.Wait()
+
+		select {
+		case ttl := <-refreshed:
+			assert.Equal(t, 10*time.Minute+24*time.Hour, ttl)
+		default:
+			t.Fatal("The channel should be refreshed in the background")
+		}
+	})
+
+	t.Run("Failed refresh is not retried right away", func(t *testing.T) {
+		const errorKey = "telegram:refresh-error:testchannel:1:0:0:"
+
+		var mu sync.Mutex
+
+		stored := map[string][]byte{dataKey: cachedChannelAged(15)}
+
+		mockCache := &MockCache{
+			GetFunc: func(_ context.Context, key string) ([]byte, error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if value, ok := stored[key]; ok {
+					return value, nil
+				}
+
+				return nil, cache.ErrCacheMiss
+			},
+			SetFunc: func(_ context.Context, key string, value []byte, ttl time.Duration) error {
+				assert.Equal(t, errorKey, key)
+				assert.Equal(t, time.Minute, ttl)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				stored[key] = value
+				return nil
+			},
+		}
+
+		var scrapes atomic.Int32
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				scrapes.Add(1)
+				return nil, entity.ErrUnavailable
+			},
+		}
+
+		var background sync.WaitGroup
+
+		mux := newMux(mockCache, mockScraper, &background)
+
+		for range 3 {
+			rec := get(mux)
+			background.Wait()
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "STALE", rec.Header().Get("X-CACHE-STATUS"))
+		}
+
+		assert.Equal(t, int32(1), scrapes.Load())
+	})
+
+	t.Run("Served if t.me fails", func(t *testing.T) {
+		mockCache := &MockCache{
+			GetFunc: func(_ context.Context, key string) ([]byte, error) {
+				if key == dataKey {
+					return cachedChannelAged(60), nil
+				}
+
+				return nil, cache.ErrCacheMiss
+			},
+			SetFunc: func(_ context.Context, key string, _ []byte, _ time.Duration) error {
+				t.Errorf("Nothing should be cached, got %s", key)
+				return nil
+			},
+		}
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				return nil, entity.ErrUnavailable
+			},
+		}
+
+		rec := get(newMux(mockCache, mockScraper, &sync.WaitGroup{}))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "STALE", rec.Header().Get("X-CACHE-STATUS"))
+		assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "Stale Channel", rec.Body.String())
+	})
+
+	t.Run("Not served for a missing channel", func(t *testing.T) {
+		mockCache := &MockCache{
+			GetFunc: func(_ context.Context, key string) ([]byte, error) {
+				if key == dataKey {
+					return cachedChannelAged(60), nil
+				}
+
+				return nil, cache.ErrCacheMiss
+			},
+			SetFunc: func(_ context.Context, key string, _ []byte, _ time.Duration) error {
+				assert.Equal(t, "telegram:channel-error:testchannel", key)
+				return nil
+			},
+		}
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				return nil, entity.ErrNotFound
+			},
+		}
+
+		rec := get(newMux(mockCache, mockScraper, &sync.WaitGroup{}))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Windows are configurable", func(t *testing.T) {
+		t.Setenv("STALE_WHILE_REVALIDATE", "0")
+		t.Setenv("STALE_IF_ERROR", "30")
+
+		mockCache := &MockCache{
+			GetFunc: func(_ context.Context, key string) ([]byte, error) {
+				if key == dataKey {
+					return cachedChannelAged(15), nil
+				}
+
+				return nil, cache.ErrCacheMiss
+			},
+		}
+
+		scrapes := 0
+
+		mockScraper := &MockScraper{
+			ScrapeFunc: func(_ context.Context, _ string, _ entity.ScrapeParams) (*entity.Channel, error) {
+				scrapes++
+				return nil, entity.ErrUnavailable
+			},
+		}
+
+		mux := newMux(mockCache, mockScraper, &sync.WaitGroup{})
+
+		// Not served while revalidating, but still within the stale-if-error window
+		rec := get(mux)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "STALE", rec.Header().Get("X-CACHE-STATUS"))
+		assert.Equal(t, 1, scrapes)
+
+		mockCache.GetFunc = func(_ context.Context, key string) ([]byte, error) {
+			if key == dataKey {
+				return cachedChannelAged(45), nil
+			}
+
+			return nil, cache.ErrCacheMiss
+		}
+
+		rec = get(mux)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, 2, scrapes)
+	})
+}
+
+func TestTelegramHandler_GetChannelInfo(t *testing.T) {
+	mockCache := &MockCache{
+		GetFunc: func(_ context.Context, key string) ([]byte, error) {
+			assert.Contains(t, []string{
+				"telegram:channel-data:testchannel:1:0:0:",
+				"telegram:channel-error:testchannel",
+			}, key)
+			return nil, cache.ErrCacheMiss
+		},
+		SetFunc: func(_ context.Context, _ string, _ []byte, ttl time.Duration) error {
+			assert.Equal(t, time.Hour+24*time.Hour, ttl)
+			return nil
+		},
+	}
+
+	mockScraper := &MockScraper{
+		ScrapeFunc: func(_ context.Context, username string, params entity.ScrapeParams) (*entity.Channel, error) {
+			assert.Equal(t, "testchannel", username)
+			assert.Equal(t, 1, params.Depth)
+			return &entity.Channel{
+				Username:    "testchannel",
+				Title:       "Test Channel",
+				Description: "Channel description",
+				URL:         "https://t.me/s/testchannel",
+				Verified:    true,
+				Subscribers: 1500,
+				Posts:       []entity.Post{{ID: 1}},
+			}, nil
+		},
+	}
+
+	mux := http.NewServeMux()
+	rest.NewTelegramHandler(mux, mockCache, mockScraper, &MockGenerator{}, &sync.WaitGroup{})
+
+	req := httptest.NewRequest(http.MethodGet, "/telegram/channel/testchannel/info", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "MISS", rec.Header().Get("X-CACHE-STATUS"))
+	assert.JSONEq(t, `{
+		"username": "testchannel",
+		"title": "Test Channel",
+		"description": "Channel description",
+		"url": "https://t.me/s/testchannel",
+		"image_url": "",
+		"verified": true,
+		"subscribers": 1500,
+		"photos": 0,
+		"videos": 0,
+		"links": 0,
+		"files": 0
+	}`, rec.Body.String())
+}
+
+func TestTelegramHandler_GetPost(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+		expectedBodyPart   string
+	}{
+		{
+			name:               "Post as JSON",
+			url:                "/telegram/channel/testchannel/42?format=json",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type":   "application/json; charset=utf-8",
+				"X-CACHE-STATUS": "MISS",
+			},
+			expectedBodyPart: "generated json",
+		},
+		{
+			name:               "Post as HTML",
+			url:                "/telegram/channel/testchannel/42?format=html",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type": "text/html; charset=utf-8",
+			},
+			expectedBodyPart: "generated html",
+		},
+		{
+			name:               "Post as a one-item feed by default",
+			url:                "/telegram/channel/testchannel/42",
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type": "application/rss+xml; charset=utf-8",
+			},
+			expectedBodyPart: "generated rss",
+		},
+		{
+			name:               "Invalid post ID",
+			url:                "/telegram/channel/testchannel/abc",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBodyPart:   "postID must be a positive integer",
+		},
+		{
+			name:               "Invalid format",
+			url:                "/telegram/channel/testchannel/42?format=podcast",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBodyPart:   "format must be rss, atom, json or html",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache := &MockCache{
+				GetFunc: func(_ context.Context, _ string) ([]byte, error) {
+					return nil, cache.ErrCacheMiss
+				},
+				SetFunc: func(_ context.Context, key string, _ []byte, _ time.Duration) error {
+					assert.Contains(t, key, "telegram:post:testchannel:42:")
+					return nil
+				},
+			}
+
+			mockScraper := &MockScraper{
+				ScrapePostFunc: func(_ context.Context, username string, postID int) (*entity.Channel, error) {
+					assert.Equal(t, "testchannel", username)
+					assert.Equal(t, 42, postID)
+					return &entity.Channel{
+						Username: "testchannel",
+						Posts:    []entity.Post{{ID: 42}},
+					}, nil
+				},
+			}
+
+			mockGenerator := &MockGenerator{
+				GeneratePostFunc: func(_ *entity.Channel, params *entity.PostParams) ([]byte, error) {
+					return []byte("generated " + params.Format), nil
+				},
+			}
+
+			mux := http.NewServeMux()
+			rest.NewTelegramHandler(mux, mockCache, mockScraper, mockGenerator, &sync.WaitGroup{})
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+
+			for key, value := range tt.expectedHeaders {
+				assert.Equal(t, value, rec.Header().Get(key))
+			}
+
+			assert.Contains(t, rec.Body.String(), tt.expectedBodyPart)
+		})
+	}
+}
@@ -17,3 +17,10 @@ type Cache interface {
 	// Close releases any resources used by the cache
 	Close() error
 }
+
+// Locker is implemented by caches shared by several tgfeed instances
+type Locker interface {
+	// TryLock acquires the lock for the key unless it's already held, for at most ttl.
+	// ok is false if the lock is held by someone else, unlock releases the acquired lock
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
@@ -0,0 +1,443 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nDmitry/tgfeed/internal/app"
+)
+
+const (
+	// FileCacheMaxBytesDefault is the default size budget of the file cache
+	FileCacheMaxBytesDefault = 512 << 20
+
+	// FileCacheSweepInterval is how often expired entries are removed from disk
+	FileCacheSweepInterval = 5 * time.Minute
+
+	fileCacheExt = ".cache"
+	// Every file starts with the expiration time in Unix nanoseconds
+	fileCacheHeaderSize = 8
+)
+
+// ErrCacheClosed is returned when the cache is used after Close
+var ErrCacheClosed = errors.New("cache is closed")
+
+// FileCache implements the Cache interface with a file per key in a directory,
+// so the cache survives restarts. Files of expired entries are removed periodically,
+// along with the least recently used ones once the cache grows over its size budget
+type FileCache struct {
+	dir      string
+	maxBytes int64
+
+	// Readers and writers hold the read lock, so Close waits for them to finish
+	mu     sync.RWMutex
+	closed bool
+
+	sizeMu sync.Mutex
+	size   int64
+
+	// Sweeps the cache ahead of time once it grows over the budget
+	sweepCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+	now     func() time.Time
+}
+
+// NewFileCache creates a cache in dir holding up to maxBytes and starts sweeping it every interval
+func NewFileCache(dir string, maxBytes int64, interval time.Duration) (*FileCache, error) {
+	if maxBytes <= 0 {
+		maxBytes = FileCacheMaxBytesDefault
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &FileCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		sweepCh:  make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+		now:      time.Now,
+	}
+
+	// Counts the size of the entries left from the previous run
+	entries, err := c.scan()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		c.size += e.size
+	}
+
+	c.evict(entries)
+
+	go c.runSweeper(interval)
+
+	return c, nil
+}
+
+// Get retrieves a value from disk
+func (c *FileCache) Get(_ context.Context, key string) ([]byte, error) {
+	path := c.path(key)
+	value, err := c.read(path)
+
+	if errors.Is(err, errFileExpired) {
+		c.removeExpired(path)
+		return nil, ErrCacheMiss
+	}
+
+	return value, err
+}
+
+var errFileExpired = errors.New("cache file is expired")
+
+func (c *FileCache) read(path string) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrCacheClosed
+	}
+
+	data, err := os.ReadFile(path) // nolint: gosec
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+
+	if len(data) < fileCacheHeaderSize || !now.Before(fileExpiresAt(data)) {
+		return nil, errFileExpired
+	}
+
+	// The modification time tracks the last use for eviction
+	_ = os.Chtimes(path, now, now)
+
+	return data[fileCacheHeaderSize:], nil
+}
+
+// removeExpired deletes an expired file unless a concurrent Set has replaced it with a fresh one
+func (c *FileCache) removeExpired(path string) {
+	// Set holds the read lock until its file is renamed in place
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return
+	}
+
+	if expiresAt, err := readFileExpiresAt(path); err == nil && c.now().Before(expiresAt) {
+		return
+	}
+
+	c.removeFile(path, info.Size())
+}
+
+// Set atomically writes a value to disk with the specified TTL
+// If ttl is 0, the value will not be cached
+func (c *FileCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // Skip caching if TTL is 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return ErrCacheClosed
+	}
+
+	data := make([]byte, fileCacheHeaderSize+len(value))
+	binary.BigEndian.PutUint64(data, uint64(c.now().Add(ttl).UnixNano())) // nolint: gosec
+	copy(data[fileCacheHeaderSize:], value)
+
+	// Readers never see a partially written file
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() // nolint: errcheck,gosec
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() // nolint: errcheck,gosec
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	size, err := c.replaceFile(tmp.Name(), c.path(key), int64(len(data)))
+
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if size > c.maxBytes {
+		select {
+		case c.sweepCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Close stops the sweeper, waits for pending reads and writes to finish
+// and flushes the directory to disk
+func (c *FileCache) Close() error {
+	c.mu.Lock()
+
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.closed = true
+	c.mu.Unlock()
+
+	close(c.stopCh)
+	<-c.doneCh
+
+	dir, err := os.Open(c.dir)
+
+	if err != nil {
+		return fmt.Errorf("failed to flush cache directory: %w", err)
+	}
+
+	defer dir.Close() // nolint: errcheck
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("failed to flush cache directory: %w", err)
+	}
+
+	return nil
+}
+
+func (c *FileCache) runSweeper(interval time.Duration) {
+	defer close(c.doneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+		case <-c.sweepCh:
+		}
+
+		if err := c.sweep(); err != nil {
+			app.Logger().Error("Failed to sweep file cache", "error", err)
+		}
+	}
+}
+
+type fileCacheEntry struct {
+	path    string
+	size    int64
+	usedAt  time.Time
+	expired bool
+}
+
+// replaceFile renames the written file in place and returns the new size of the cache.
+// Concurrent writes of the same key take turns, so each replaced file is subtracted once
+func (c *FileCache) replaceFile(tmpPath, path string, size int64) (int64, error) {
+	c.sizeMu.Lock()
+	defer c.sizeMu.Unlock()
+
+	if info, err := os.Stat(path); err == nil {
+		size -= info.Size()
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return c.size, err
+	}
+
+	c.size += size
+
+	return c.size, nil
+}
+
+// sweep removes expired entries, then the least recently used ones until the cache fits its budget
+func (c *FileCache) sweep() error {
+	entries, err := c.scan()
+
+	if err != nil {
+		return err
+	}
+
+	c.evict(entries)
+
+	return nil
+}
+
+// scan lists the entries of the cache, removing leftovers of interrupted writes
+func (c *FileCache) scan() ([]fileCacheEntry, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	now := c.now()
+	entries := make([]fileCacheEntry, 0, len(dirEntries))
+
+	for _, de := range dirEntries {
+		path := filepath.Join(c.dir, de.Name())
+
+		info, err := de.Info()
+
+		if err != nil {
+			continue
+		}
+
+		// Leftovers of writes interrupted by a crash
+		if strings.HasPrefix(de.Name(), ".tmp-") && now.Sub(info.ModTime()) > time.Hour {
+			_ = os.Remove(path)
+			continue
+		}
+
+		if de.IsDir() || filepath.Ext(de.Name()) != fileCacheExt {
+			continue
+		}
+
+		entry := fileCacheEntry{path: path, size: info.Size(), usedAt: info.ModTime()}
+		expiresAt, err := readFileExpiresAt(path)
+
+		entry.expired = err != nil || !now.Before(expiresAt)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// evict removes expired entries, then the least recently used ones until the listed entries fit the budget.
+// The size of the cache is adjusted by the removed files, as writes may have changed it since the listing
+func (c *FileCache) evict(entries []fileCacheEntry) {
+	// Expired entries go first, then the least recently used ones
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].expired != entries[j].expired {
+			return entries[i].expired
+		}
+
+		return entries[i].usedAt.Before(entries[j].usedAt)
+	})
+
+	var size int64
+
+	for _, e := range entries {
+		size += e.size
+	}
+
+	for _, e := range entries {
+		if !e.expired && size <= c.maxBytes {
+			break
+		}
+
+		if c.removeUnchanged(e) {
+			size -= e.size
+		}
+	}
+}
+
+// removeUnchanged deletes the file of the entry unless it has been written or read since the sweep listed it
+func (c *FileCache) removeUnchanged(e fileCacheEntry) bool {
+	// Writes hold the read lock until their files are renamed in place
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(e.path)
+
+	// Already removed by Get, which has subtracted its size
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+
+	if err != nil || !info.ModTime().Equal(e.usedAt) {
+		return false
+	}
+
+	return c.removeFile(e.path, info.Size())
+}
+
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+fileCacheExt)
+}
+
+func (c *FileCache) removeFile(path string, size int64) bool {
+	if err := os.Remove(path); err != nil {
+		return false
+	}
+
+	c.addSize(-size)
+
+	return true
+}
+
+func (c *FileCache) addSize(delta int64) int64 {
+	c.sizeMu.Lock()
+	defer c.sizeMu.Unlock()
+
+	c.size += delta
+
+	return c.size
+}
+
+func readFileExpiresAt(path string) (time.Time, error) {
+	f, err := os.Open(path) // nolint: gosec
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	defer f.Close() // nolint: errcheck
+
+	header := make([]byte, fileCacheHeaderSize)
+
+	if _, err := io.ReadFull(f, header); err != nil {
+		return time.Time{}, err
+	}
+
+	return fileExpiresAt(header), nil
+}
+
+func fileExpiresAt(data []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))) // nolint: gosec
+}
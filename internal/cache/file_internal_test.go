@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache_GetSet(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c, err := NewFileCache(dir, 1024, time.Hour)
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+	require.NoError(t, c.Set(ctx, "key", []byte("updated"), time.Minute))
+
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("updated"), val)
+	assert.Equal(t, int64(fileCacheHeaderSize+len("updated")), c.size)
+
+	// Zero TTL disables caching
+	require.NoError(t, c.Set(ctx, "skipped", []byte("value"), 0))
+
+	_, err = c.Get(ctx, "skipped")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	// No temporary files are left behind
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+
+	assert.ErrorIs(t, c.Set(ctx, "key", []byte("value"), time.Minute), ErrCacheClosed)
+
+	// Entries survive a restart
+	c, err = NewFileCache(dir, 1024, time.Hour)
+	require.NoError(t, err)
+
+	defer c.Close() // nolint: errcheck
+
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("updated"), val)
+	assert.Equal(t, int64(fileCacheHeaderSize+len("updated")), c.size)
+}
+
+func TestFileCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c, err := NewFileCache(t.TempDir(), 1024, time.Hour)
+	require.NoError(t, err)
+
+	defer c.Close() // nolint: errcheck
+
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	now = now.Add(59 * time.Second)
+
+	_, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+
+	_, err = c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.NoFileExists(t, c.path("key"))
+	assert.Zero(t, c.size)
+
+	// A fresh value written after Get has found the expired one is kept
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	now = now.Add(time.Minute)
+
+	_, err = c.read(c.path("key"))
+	require.ErrorIs(t, err, errFileExpired)
+
+	require.NoError(t, c.Set(ctx, "key", []byte("fresh"), time.Minute))
+	c.removeExpired(c.path("key"))
+
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("fresh"), val)
+}
+
+func TestFileCache_Sweep(t *testing.T) {
+	ctx := context.Background()
+	clock := &testClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dir := t.TempDir()
+
+	// Room for three 10-byte values with their headers
+	c, err := NewFileCache(dir, 3*(fileCacheHeaderSize+10), time.Hour)
+	require.NoError(t, err)
+
+	defer c.Close() // nolint: errcheck
+
+	// Sets over the budget trigger a sweep in the background
+	c.now = clock.Now
+
+	set := func(key string, ttl time.Duration) {
+		require.NoError(t, c.Set(ctx, key, []byte("0123456789"), ttl))
+		// Spread the modification times, as some filesystems round them
+		_ = os.Chtimes(c.path(key), clock.Now(), clock.Now())
+		clock.Add(time.Second)
+	}
+
+	set("a", time.Hour)
+	set("b", time.Minute)
+	set("c", time.Hour)
+
+	// "a" becomes the most recently used one
+	_, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+
+	clock.Add(time.Minute)
+
+	// Leftover of an interrupted write
+	tmp := filepath.Join(dir, ".tmp-123")
+	require.NoError(t, os.WriteFile(tmp, []byte("partial"), 0o600))
+	require.NoError(t, os.Chtimes(tmp, clock.Now().Add(-2*time.Hour), clock.Now().Add(-2*time.Hour)))
+
+	set("d", time.Hour)
+	set("e", time.Hour)
+
+	require.NoError(t, c.sweep())
+
+	// The expired "b" is removed first, then the least recently used "c"
+	assert.NoFileExists(t, c.path("b"))
+	assert.NoFileExists(t, c.path("c"))
+	assert.NoFileExists(t, tmp)
+
+	for _, key := range []string{"a", "d", "e"} {
+		_, err = c.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+
+	assert.Equal(t, int64(3*(fileCacheHeaderSize+10)), c.addSize(0))
+}
+
+func TestFileCache_ConcurrentSetSweep(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Room for a few entries, so sweeps evict some
+	c, err := NewFileCache(dir, 10*(fileCacheHeaderSize+100), time.Hour)
+	require.NoError(t, err)
+
+	defer c.Close() // nolint: errcheck
+
+	var wg sync.WaitGroup
+
+	for i := range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range 50 {
+				// Writers share keys and write values of different sizes
+				key := fmt.Sprintf("key-%d", (i+j)%20)
+				assert.NoError(t, c.Set(ctx, key, make([]byte, 10*(1+j%10)), time.Hour))
+			}
+		}()
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for range 20 {
+			assert.NoError(t, c.sweep())
+		}
+	}()
+
+	wg.Wait()
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var size int64
+
+	for _, f := range files {
+		info, err := f.Info()
+		require.NoError(t, err)
+
+		size += info.Size()
+	}
+
+	assert.Equal(t, size, c.addSize(0))
+}
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
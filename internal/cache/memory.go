@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCacheMaxBytesDefault is the default size cap of the in-memory cache
+const MemoryCacheMaxBytesDefault = 64 << 20
+
+// MemoryCache implements the Cache interface in the process memory,
+// evicting the least recently used entries once the size cap is reached
+type MemoryCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	// Front is the most recently used entry
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates an in-memory cache holding up to maxBytes of keys and values
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	if maxBytes <= 0 {
+		maxBytes = MemoryCacheMaxBytesDefault
+	}
+
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get retrieves a value from memory
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	entry := el.Value.(*memoryEntry)
+
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		return nil, ErrCacheMiss
+	}
+
+	c.order.MoveToFront(el)
+
+	return entry.value, nil
+}
+
+// Set stores a value in memory with the specified TTL
+// If ttl is 0, the value will not be cached
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // Skip caching if TTL is 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	entry := &memoryEntry{
+		key:       key,
+		value:     value,
+		expiresAt: c.now().Add(ttl),
+	}
+
+	// A value that doesn't fit at all would only flush the whole cache
+	if entry.size() > c.maxBytes {
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	c.size += entry.size()
+
+	c.evict()
+
+	return nil
+}
+
+// Close drops all entries
+func (c *MemoryCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.size = 0
+
+	return nil
+}
+
+// evict removes expired entries and then the least recently used ones until the cache fits its cap
+func (c *MemoryCache) evict() {
+	if c.size <= c.maxBytes {
+		return
+	}
+
+	now := c.now()
+
+	for el := c.order.Back(); el != nil; {
+		prev := el.Prev()
+
+		if !now.Before(el.Value.(*memoryEntry).expiresAt) {
+			c.remove(el)
+		}
+
+		el = prev
+	}
+
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	entry := el.Value.(*memoryEntry)
+
+	c.order.Remove(el)
+	delete(c.entries, entry.key)
+	c.size -= entry.size()
+}
+
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_GetSet(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(1024)
+
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	val, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+
+	require.NoError(t, c.Set(ctx, "key", []byte("updated"), time.Minute))
+
+	val, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("updated"), val)
+	assert.Equal(t, int64(len("key")+len("updated")), c.size)
+
+	// Zero TTL disables caching
+	require.NoError(t, c.Set(ctx, "skipped", []byte("value"), 0))
+
+	_, err = c.Get(ctx, "skipped")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestMemoryCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMemoryCache(1024)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	now = now.Add(59 * time.Second)
+
+	_, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+
+	_, err = c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.Zero(t, c.size)
+	assert.Empty(t, c.entries)
+}
+
+func TestMemoryCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Room for three 10-byte entries
+	c := NewMemoryCache(30)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("123456789"), time.Hour))
+	require.NoError(t, c.Set(ctx, "b", []byte("123456789"), time.Hour))
+	require.NoError(t, c.Set(ctx, "c", []byte("123456789"), time.Hour))
+
+	// "a" becomes the most recently used one, so "b" goes first
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "d", []byte("123456789"), time.Hour))
+
+	_, err = c.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	for _, key := range []string{"a", "c", "d"} {
+		_, err = c.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+
+	// Expired entries are dropped before the recently used ones
+	require.NoError(t, c.Set(ctx, "e", []byte("123456789"), time.Minute))
+
+	_, err = c.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	now = now.Add(2 * time.Minute)
+
+	require.NoError(t, c.Set(ctx, "f", []byte("123456789"), time.Hour))
+
+	for _, key := range []string{"c", "d", "f"} {
+		_, err = c.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+
+	assert.Equal(t, int64(30), c.size)
+
+	// Values larger than the cap are not cached
+	require.NoError(t, c.Set(ctx, "g", make([]byte, 100), time.Hour))
+
+	_, err = c.Get(ctx, "g")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.Len(t, c.entries, 3)
+}
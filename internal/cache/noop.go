@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// NoopCache implements the Cache interface without storing anything
+type NoopCache struct{}
+
+// Get always reports a cache miss
+func (NoopCache) Get(_ context.Context, _ string) ([]byte, error) {
+	return nil, ErrCacheMiss
+}
+
+// Set discards the value
+func (NoopCache) Set(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+	return nil
+}
+
+// Close does nothing
+func (NoopCache) Close() error {
+	return nil
+}
@@ -2,16 +2,28 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
+	"github.com/nDmitry/tgfeed/internal/app"
 	"github.com/redis/go-redis/v9"
 )
 
 // ErrCacheMiss is returned when a key is not found in the cache
 var ErrCacheMiss = errors.New("cache miss")
 
-// RedisCache implements the Cache interface using Redis
+// unlockScript deletes the lock only if it's still held by the same owner,
+// so an expired lock taken over by another instance stays in place
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// RedisCache implements the Cache and Locker interfaces using Redis
 type RedisCache struct {
 	client *redis.Client
 }
@@ -51,6 +63,33 @@ func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time
 	return c.client.Set(ctx, key, value, ttl).Err()
 }
 
+// TryLock acquires a lock in Redis expiring after ttl
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token := make([]byte, 16)
+
+	if _, err := rand.Read(token); err != nil {
+		return nil, false, err
+	}
+
+	owner := hex.EncodeToString(token)
+	ok, err := c.client.SetNX(ctx, key, owner, ttl).Result()
+
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := unlockScript.Run(unlockCtx, c.client, []string{key}, owner).Err(); err != nil {
+			app.Logger().Error("Failed to release lock", "key", key, "error", err)
+		}
+	}
+
+	return unlock, true, nil
+}
+
 // Close releases the Redis client
 func (c *RedisCache) Close() error {
 	return c.client.Close()
@@ -3,11 +3,19 @@ package entity
 import "time"
 
 type Channel struct {
-	Username string
-	Title    string
-	URL      string
-	ImageURL string
-	Posts    []Post
+	Username    string
+	Title       string
+	Description string
+	URL         string
+	ImageURL    string
+	Verified    bool
+	// Counters shown in the channel info, abbreviated ones like "1.2K" are approximate
+	Subscribers int
+	Photos      int
+	Videos      int
+	Links       int
+	Files       int
+	Posts       []Post
 }
 
 type Post struct {
@@ -16,10 +24,36 @@ type Post struct {
 	URL         string
 	Title       string
 	ContentHTML string
+	// Author signature, set only in channels with signed messages
+	Author string
 	// A preview image that goes to enclosure
 	Preview *Image
+	// A link preview card shown below the text
+	LinkPreview *LinkPreview
 	// Collection of all images in the post
 	Images []Image
+	// Collection of all videos in the post
+	Videos []Video
+	// Collection of all voice messages and audio files in the post
+	Audios []Audio
+	// Collection of all documents shared in the post
+	Attachments []Attachment
+	// A poll attached to the post
+	Poll *Poll
+	// Source of a forwarded post
+	ForwardedFrom *Forward
+	// A message this post replies to
+	ReplyTo *Reply
+	// Number of views
+	Views int
+	// Reaction counters in the order shown by t.me
+	Reactions []Reaction
+	// Whether the post was edited after publishing
+	Edited bool
+	// Whether the post is marked as an ad
+	Sponsored bool
+	// Hashtags of the post without the leading "#"
+	Tags []string
 	// Date and time of the post in RFC3339 format.
 	Datetime time.Time
 }
@@ -30,4 +64,96 @@ type Image struct {
 	Type string
 	// In bytes
 	Size int64
+	// In pixels as laid out at t.me, 0 if unknown
+	Width  int
+	Height int
+}
+
+// Video represents a video attachment with its metadata
+type Video struct {
+	URL  string
+	Type string
+	// A poster image shown before the video starts
+	ThumbnailURL string
+	// In seconds
+	Duration int
+	// In bytes
+	Size int64
+	// In pixels as laid out at t.me, 0 if unknown
+	Width  int
+	Height int
+}
+
+// Audio represents a voice message or an audio file with its metadata
+type Audio struct {
+	// Empty for audio files that can't be played at t.me
+	URL       string
+	Type      string
+	Title     string
+	Performer string
+	// In seconds
+	Duration int
+	// In bytes
+	Size int64
+}
+
+// Attachment represents a document shared in a post
+type Attachment struct {
+	// File name, e.g. "report.pdf"
+	Name string
+	// Human readable size as shown by t.me, e.g. "1.2 MB"
+	SizeText string
+	// Lowercase file extension without a dot, e.g. "pdf"
+	Extension string
+}
+
+// Poll represents a Telegram poll with its results
+type Poll struct {
+	Question string
+	// Poll type as shown by t.me, e.g. "Anonymous poll" or "Anonymous quiz"
+	Type    string
+	Options []PollOption
+	// Total number of voters
+	Voters int
+}
+
+// PollOption represents a poll answer with its share of votes
+type PollOption struct {
+	Text string
+	// Share of votes in percent
+	Percent int
+}
+
+// Forward describes the source of a forwarded post
+type Forward struct {
+	// Name of the source channel or user
+	Name string
+	// Link to the source channel, empty if the source is hidden
+	ChannelURL string
+	// Link to the original post, empty if the source is hidden
+	PostURL string
+}
+
+// Reply describes a message a post replies to
+type Reply struct {
+	Author string
+	// A short snippet of the replied-to message
+	Text string
+	URL  string
+}
+
+// Reaction represents a reaction counter of a post
+type Reaction struct {
+	// Empty for custom emoji that t.me renders as images
+	Emoji string
+	Count int
+}
+
+// LinkPreview represents a preview card of a link mentioned in a post
+type LinkPreview struct {
+	URL         string
+	SiteName    string
+	Title       string
+	Description string
+	ImageURL    string
 }
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound means the channel or the post doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrPrivate means the channel exists but has no public preview at t.me/s
+	ErrPrivate = errors.New("private")
+
+	// ErrRateLimited means t.me asked to slow down
+	ErrRateLimited = errors.New("rate limited by t.me")
+
+	// ErrUpstream means t.me responded with an error or an unexpected page
+	ErrUpstream = errors.New("bad response from t.me")
+
+	// ErrUnavailable means t.me couldn't be reached
+	ErrUnavailable = errors.New("t.me is unavailable")
+)
+
+// RetryableError wraps an upstream error with a delay suggested by t.me
+type RetryableError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
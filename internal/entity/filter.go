@@ -0,0 +1,603 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FilterMaxLength limits the length of a filter expression in characters
+const FilterMaxLength = 1000
+
+// FilterTarget is the plain-text view of a post a filter is evaluated against
+type FilterTarget struct {
+	Title string
+	// Text of the post and its link preview without markup
+	Text string
+	// Links of the post and its link preview
+	Links []string
+	// Hashtags without the leading "#"
+	Hashtags []string
+	Views    int
+	HasPhoto bool
+	HasVideo bool
+	HasAudio bool
+	HasFile  bool
+	HasPoll  bool
+	HasLink  bool
+	// Forwarded from another chat
+	HasForward bool
+}
+
+// Filter is a parsed filter expression, e.g.
+//
+//	(title:bitcoin OR hashtag:crypto) AND NOT "price prediction" has:photo views>1000
+//
+// Terms next to each other are joined with AND, "-term" is a shortcut for NOT.
+// Words and "quoted phrases" are matched case-insensitively, /regex/ literals are RE2
+// and match case-insensitively with the "i" flag: /^breaking/i.
+type Filter struct {
+	source string
+	root   filterNode
+}
+
+// FilterError is a filter syntax error at a 1-based character position
+type FilterError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos)
+}
+
+// ParseFilter parses a filter expression, an empty expression gives a nil filter
+func ParseFilter(source string) (*Filter, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	if n := len([]rune(source)); n > FilterMaxLength {
+		return nil, &FilterError{Pos: FilterMaxLength + 1, Msg: fmt.Sprintf("expression is longer than %d characters", FilterMaxLength)}
+	}
+
+	tokens, err := lexFilter(source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens, end: len([]rune(source)) + 1}
+	root, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok != nil {
+		return nil, &FilterError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+
+	return &Filter{source: source, root: root}, nil
+}
+
+// Match checks if the post matches the filter, a nil filter matches everything
+func (f *Filter) Match(target *FilterTarget) bool {
+	if f == nil {
+		return true
+	}
+
+	return f.root.match(target)
+}
+
+// String returns the source expression of the filter
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.source
+}
+
+type filterTokenKind int
+
+const (
+	tokenWord filterTokenKind = iota
+	tokenPhrase
+	tokenRegex
+	tokenLParen
+	tokenRParen
+	tokenNot
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	// For regex literals
+	flags string
+	// 1-based positions of the first character and right after the last one
+	pos int
+	end int
+}
+
+var filterFields = map[string]bool{
+	"title":   true,
+	"text":    true,
+	"link":    true,
+	"hashtag": true,
+	"has":     true,
+}
+
+// lexFilter splits the expression into tokens
+// nolint: cyclop
+func lexFilter(source string) ([]*filterToken, error) {
+	runes := []rune(source)
+	tokens := make([]*filterToken, 0)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, &filterToken{kind: tokenLParen, text: "(", pos: pos, end: pos + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, &filterToken{kind: tokenRParen, text: ")", pos: pos, end: pos + 1})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, &filterToken{kind: tokenNot, text: "-", pos: pos, end: pos + 1})
+			i++
+		case r == '"' || r == '/':
+			text, next, ok := scanQuoted(runes, i)
+
+			if !ok {
+				if r == '"' {
+					return nil, &FilterError{Pos: pos, Msg: "unterminated quoted phrase"}
+				}
+
+				return nil, &FilterError{Pos: pos, Msg: "unterminated regular expression"}
+			}
+
+			tok := &filterToken{kind: tokenPhrase, text: text, pos: pos}
+
+			if r == '/' {
+				tok.kind = tokenRegex
+
+				for next < len(runes) && unicode.IsLetter(runes[next]) {
+					tok.flags += string(runes[next])
+					next++
+				}
+			}
+
+			tok.end = next + 1
+			tokens = append(tokens, tok)
+			i = next
+		default:
+			start := i
+
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+
+				// A field followed by a phrase or a regex literal, e.g. title:"some phrase"
+				if runes[i-1] == ':' && filterFields[strings.ToLower(string(runes[start:i-1]))] &&
+					i < len(runes) && (runes[i] == '"' || runes[i] == '/') {
+					break
+				}
+			}
+
+			tokens = append(tokens, &filterToken{kind: tokenWord, text: string(runes[start:i]), pos: pos, end: i + 1})
+		}
+	}
+
+	return tokens, nil
+}
+
+// scanQuoted scans a phrase or a regex literal starting with a quote at i,
+// a backslash escapes the closing quote
+func scanQuoted(runes []rune, i int) (string, int, bool) {
+	quote := runes[i]
+
+	var sb strings.Builder
+
+	for j := i + 1; j < len(runes); j++ {
+		switch {
+		case runes[j] == '\\' && j+1 < len(runes) && runes[j+1] == quote:
+			sb.WriteRune(quote)
+			j++
+		case runes[j] == quote:
+			return sb.String(), j + 1, true
+		default:
+			sb.WriteRune(runes[j])
+		}
+	}
+
+	return "", 0, false
+}
+
+type filterParser struct {
+	tokens []*filterToken
+	i      int
+	// Position right after the end of the expression
+	end int
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.i >= len(p.tokens) {
+		return nil
+	}
+
+	return p.tokens[p.i]
+}
+
+func (p *filterParser) next() *filterToken {
+	tok := p.peek()
+
+	if tok != nil {
+		p.i++
+	}
+
+	return tok
+}
+
+func isOperator(tok *filterToken, op string) bool {
+	return tok != nil && tok.kind == tokenWord && tok.text == op
+}
+
+// parseOr parses terms joined with OR, which binds weaker than AND
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for isOperator(p.peek(), "OR") {
+		p.next()
+
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses terms joined with AND or just written next to each other
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+
+		if tok == nil || tok.kind == tokenRParen || isOperator(tok, "OR") {
+			return left, nil
+		}
+
+		if isOperator(tok, "AND") {
+			p.next()
+		}
+
+		right, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &andNode{left: left, right: right}
+	}
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	tok := p.peek()
+
+	if tok != nil && (tok.kind == tokenNot || isOperator(tok, "NOT")) {
+		p.next()
+
+		operand, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// nolint: cyclop
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	tok := p.next()
+
+	if tok == nil {
+		return nil, &FilterError{Pos: p.end, Msg: "unexpected end of expression"}
+	}
+
+	switch tok.kind {
+	case tokenLParen:
+		node, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing == nil || closing.kind != tokenRParen {
+			return nil, &FilterError{Pos: tok.pos, Msg: "unclosed parenthesis"}
+		}
+
+		return node, nil
+	case tokenRParen:
+		return nil, &FilterError{Pos: tok.pos, Msg: `unexpected ")"`}
+	case tokenPhrase:
+		return &termNode{field: "", matcher: newWordMatcher(tok.text)}, nil
+	case tokenRegex:
+		m, err := newRegexMatcher(tok)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &termNode{field: "", matcher: m}, nil
+	}
+
+	if tok.text == "AND" || tok.text == "OR" {
+		return nil, &FilterError{Pos: tok.pos, Msg: fmt.Sprintf("missing term before %s", tok.text)}
+	}
+
+	if node, ok, err := parseViews(tok); ok {
+		return node, err
+	}
+
+	field, value, found := strings.Cut(tok.text, ":")
+	field = strings.ToLower(field)
+
+	if !found || !filterFields[field] {
+		return &termNode{field: "", matcher: newWordMatcher(tok.text)}, nil
+	}
+
+	if field == "has" {
+		return parseHas(tok, value)
+	}
+
+	if value != "" {
+		return &termNode{field: field, matcher: newWordMatcher(value)}, nil
+	}
+
+	// The value is a phrase or a regex literal right after the colon
+	valueTok := p.peek()
+
+	if valueTok == nil || valueTok.pos != tok.end || (valueTok.kind != tokenPhrase && valueTok.kind != tokenRegex) {
+		return nil, &FilterError{Pos: tok.end, Msg: fmt.Sprintf("missing value for %s:", field)}
+	}
+
+	p.next()
+
+	if valueTok.kind == tokenPhrase {
+		return &termNode{field: field, matcher: newWordMatcher(valueTok.text)}, nil
+	}
+
+	m, err := newRegexMatcher(valueTok)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &termNode{field: field, matcher: m}, nil
+}
+
+var viewsRegex = regexp.MustCompile(`^(?i:views):?(>=|<=|>|<|=)(.*)$`)
+
+// parseViews parses a view count comparison like views>1000, ok is false if the word isn't one
+func parseViews(tok *filterToken) (filterNode, bool, error) {
+	m := viewsRegex.FindStringSubmatch(tok.text)
+
+	if m == nil {
+		return nil, false, nil
+	}
+
+	count, err := strconv.Atoi(m[2])
+
+	if err != nil || count < 0 {
+		return nil, true, &FilterError{
+			Pos: tok.pos + len([]rune(tok.text)) - len([]rune(m[2])),
+			Msg: "views must be compared with a non-negative integer",
+		}
+	}
+
+	return &viewsNode{op: m[1], count: count}, true, nil
+}
+
+var hasValues = []string{"photo", "video", "audio", "file", "poll", "link", "forward"}
+
+func parseHas(tok *filterToken, value string) (filterNode, error) {
+	value = strings.ToLower(value)
+
+	for _, v := range hasValues {
+		if v == value {
+			return &hasNode{what: value}, nil
+		}
+	}
+
+	return nil, &FilterError{
+		Pos: tok.pos + len("has:"),
+		Msg: fmt.Sprintf("has: must be one of %s", strings.Join(hasValues, ", ")),
+	}
+}
+
+type filterNode interface {
+	match(t *FilterTarget) bool
+}
+
+type andNode struct {
+	left, right filterNode
+}
+
+func (n *andNode) match(t *FilterTarget) bool {
+	return n.left.match(t) && n.right.match(t)
+}
+
+type orNode struct {
+	left, right filterNode
+}
+
+func (n *orNode) match(t *FilterTarget) bool {
+	return n.left.match(t) || n.right.match(t)
+}
+
+type notNode struct {
+	operand filterNode
+}
+
+func (n *notNode) match(t *FilterTarget) bool {
+	return !n.operand.match(t)
+}
+
+// termNode matches a word, a phrase or a regex in a field,
+// the title and the text when no field is given
+type termNode struct {
+	field   string
+	matcher textMatcher
+}
+
+func (n *termNode) match(t *FilterTarget) bool {
+	switch n.field {
+	case "title":
+		return n.matcher.contains(t.Title)
+	case "text":
+		return n.matcher.contains(t.Text)
+	case "link":
+		for _, link := range t.Links {
+			if n.matcher.contains(link) {
+				return true
+			}
+		}
+
+		return false
+	case "hashtag":
+		for _, tag := range t.Hashtags {
+			if n.matcher.equals(tag) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return n.matcher.contains(t.Title) || n.matcher.contains(t.Text)
+	}
+}
+
+type hasNode struct {
+	what string
+}
+
+func (n *hasNode) match(t *FilterTarget) bool {
+	switch n.what {
+	case "photo":
+		return t.HasPhoto
+	case "video":
+		return t.HasVideo
+	case "audio":
+		return t.HasAudio
+	case "file":
+		return t.HasFile
+	case "poll":
+		return t.HasPoll
+	case "link":
+		return t.HasLink
+	case "forward":
+		return t.HasForward
+	default:
+		return false
+	}
+}
+
+type viewsNode struct {
+	op    string
+	count int
+}
+
+func (n *viewsNode) match(t *FilterTarget) bool {
+	switch n.op {
+	case ">":
+		return t.Views > n.count
+	case ">=":
+		return t.Views >= n.count
+	case "<":
+		return t.Views < n.count
+	case "<=":
+		return t.Views <= n.count
+	default:
+		return t.Views == n.count
+	}
+}
+
+type textMatcher interface {
+	// contains checks if the text contains a match
+	contains(text string) bool
+	// equals checks if the whole text matches, for hashtags
+	equals(text string) bool
+}
+
+// wordMatcher matches words and phrases case-insensitively
+type wordMatcher struct {
+	word string
+}
+
+func newWordMatcher(word string) *wordMatcher {
+	return &wordMatcher{word: strings.ToLower(word)}
+}
+
+func (m *wordMatcher) contains(text string) bool {
+	return strings.Contains(strings.ToLower(text), m.word)
+}
+
+func (m *wordMatcher) equals(text string) bool {
+	return strings.EqualFold(text, strings.TrimPrefix(m.word, "#"))
+}
+
+// regexMatcher matches patterns the same way as exclude_re and include_re,
+// so \b works for any script and the complexity is limited
+type regexMatcher struct {
+	re *Pattern
+}
+
+func newRegexMatcher(tok *filterToken) (*regexMatcher, error) {
+	pattern := tok.text
+
+	switch tok.flags {
+	case "":
+	case "i":
+		pattern = "(?i)" + pattern
+	default:
+		return nil, &FilterError{Pos: tok.end - len([]rune(tok.flags)), Msg: fmt.Sprintf("unknown regular expression flags %q", tok.flags)}
+	}
+
+	re, err := ParsePattern(pattern)
+
+	if err != nil {
+		return nil, &FilterError{Pos: tok.pos, Msg: fmt.Sprintf("regular expression: %s", err)}
+	}
+
+	return &regexMatcher{re: re}, nil
+}
+
+func (m *regexMatcher) contains(text string) bool {
+	return m.re.MatchString(text)
+}
+
+func (m *regexMatcher) equals(text string) bool {
+	return m.re.MatchString(text)
+}
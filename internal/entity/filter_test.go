@@ -0,0 +1,134 @@
+package entity_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nDmitry/tgfeed/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter_Match(t *testing.T) {
+	post := &entity.FilterTarget{
+		Title:    "Bitcoin hits a new high",
+		Text:     "Bitcoin hits a new high\nPrice prediction for the next week #crypto #Markets",
+		Links:    []string{"https://example.com/btc"},
+		Hashtags: []string{"crypto", "Markets"},
+		Views:    1500,
+		HasPhoto: true,
+		HasLink:  true,
+	}
+
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{filter: "bitcoin", expected: true},
+		{filter: "ethereum", expected: false},
+		{filter: "bitcoin ethereum", expected: false},
+		{filter: "bitcoin AND ethereum", expected: false},
+		{filter: "bitcoin OR ethereum", expected: true},
+		{filter: "NOT ethereum", expected: true},
+		{filter: "-bitcoin", expected: false},
+		{filter: `"price prediction"`, expected: true},
+		{filter: `"prediction price"`, expected: false},
+		{filter: `title:"price prediction"`, expected: false},
+		{filter: `text:"price prediction"`, expected: true},
+		{filter: "title:bitcoin", expected: true},
+		{filter: "/^bitcoin/", expected: false},
+		{filter: "/^bitcoin/i", expected: true},
+		{filter: `title:/new \w+$/`, expected: true},
+		{filter: "link:example.com", expected: true},
+		{filter: "link:/btc$/", expected: true},
+		{filter: "hashtag:crypto", expected: true},
+		{filter: "hashtag:#markets", expected: true},
+		{filter: "hashtag:crypt", expected: false},
+		{filter: "has:photo", expected: true},
+		{filter: "has:video", expected: false},
+		{filter: "has:link", expected: true},
+		{filter: "views>1000", expected: true},
+		{filter: "views>=1500", expected: true},
+		{filter: "views<1000", expected: false},
+		{filter: "views=1500", expected: true},
+		{filter: "(ethereum OR bitcoin) has:photo views>1000", expected: true},
+		{filter: "ethereum OR bitcoin AND has:video", expected: false},
+		{filter: "(bitcoin OR ethereum) AND NOT (has:video OR views<100)", expected: true},
+		{filter: "NOT NOT bitcoin", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := entity.ParseFilter(tt.filter)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, filter.Match(post))
+			assert.Equal(t, tt.filter, filter.String())
+		})
+	}
+}
+
+func TestParseFilter_MatchUnicodeBoundaries(t *testing.T) {
+	post := &entity.FilterTarget{Title: "Кот спит", Text: "Кот спит, котлета остыла"}
+
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{filter: `/\bкот\b/`, expected: false},
+		{filter: `/\bкот\b/i`, expected: true},
+		{filter: `text:/\bкотлет\b/`, expected: false},
+		{filter: `text:/\bкотлета\b/`, expected: true},
+		{filter: `title:/\Bпит\b/`, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := entity.ParseFilter(tt.filter)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, filter.Match(post))
+		})
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	tests := []struct {
+		filter      string
+		expectedPos int
+		expectedMsg string
+	}{
+		{filter: `bitcoin "price`, expectedPos: 9, expectedMsg: "unterminated quoted phrase"},
+		{filter: `/bitcoin`, expectedPos: 1, expectedMsg: "unterminated regular expression"},
+		{filter: `(bitcoin OR ethereum`, expectedPos: 1, expectedMsg: "unclosed parenthesis"},
+		{filter: `bitcoin)`, expectedPos: 8, expectedMsg: `unexpected ")"`},
+		{filter: `bitcoin OR`, expectedPos: 11, expectedMsg: "unexpected end of expression"},
+		{filter: `OR bitcoin`, expectedPos: 1, expectedMsg: "missing term before OR"},
+		{filter: `has:everything`, expectedPos: 5, expectedMsg: "has: must be one of"},
+		{filter: `views>many`, expectedPos: 7, expectedMsg: "views must be compared with a non-negative integer"},
+		{filter: `title: bitcoin`, expectedPos: 7, expectedMsg: "missing value for title:"},
+		{filter: `text:/(unclosed/`, expectedPos: 6, expectedMsg: "regular expression: invalid pattern"},
+		{filter: `/(\p{L}|\d|_){1000}/`, expectedPos: 1, expectedMsg: "regular expression: pattern is too complex"},
+		{filter: `/bitcoin/x`, expectedPos: 10, expectedMsg: `unknown regular expression flags "x"`},
+		{filter: strings.Repeat("a", entity.FilterMaxLength+1), expectedPos: entity.FilterMaxLength + 1, expectedMsg: "longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := entity.ParseFilter(tt.filter)
+
+			var filterErr *entity.FilterError
+
+			require.ErrorAs(t, err, &filterErr)
+			assert.Equal(t, tt.expectedPos, filterErr.Pos)
+			assert.Contains(t, filterErr.Msg, tt.expectedMsg)
+		})
+	}
+}
+
+func TestParseFilter_Empty(t *testing.T) {
+	filter, err := entity.ParseFilter("  ")
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+	assert.True(t, filter.Match(&entity.FilterTarget{}), "A nil filter should match everything")
+}
@@ -3,23 +3,38 @@ package entity
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 const (
-	FormatAtom = "atom"
-	FormatRSS  = "rss"
+	FormatAtom    = "atom"
+	FormatRSS     = "rss"
+	FormatPodcast = "podcast"
+	FormatJSON    = "json"
+	FormatHTML    = "html"
 )
 
 const CacheTTLDefault = 60 // minutes
 
+const (
+	DepthDefault = 1
+	DepthMax     = 10
+)
+
+// QueryMaxLength limits the length of a t.me search query in characters
+const QueryMaxLength = 256
+
 // FeedParams represents validated request parameters for feed generation
 type FeedParams struct {
 	// Username is the Telegram channel username
 	Username string
 
-	// Format is the feed format, either "atom" or "rss"
+	// Format is the feed format, either "atom", "rss", "podcast" or "json" for JSON Feed
 	Format string
 
 	// ExcludeWords is a list of words that will exclude a post if matched
@@ -28,11 +43,118 @@ type FeedParams struct {
 	// ExcludeCaseSensitive determines if exclusion matching is case-sensitive
 	ExcludeCaseSensitive bool
 
+	// IncludeWords keeps only posts matching at least one of these words,
+	// posts matching ExcludeWords are left out anyway
+	IncludeWords []string
+
+	// IncludeCaseSensitive determines if inclusion matching is case-sensitive
+	IncludeCaseSensitive bool
+
+	// ExcludePatterns leave out posts whose plain text matches any of them
+	ExcludePatterns []*Pattern
+
+	// IncludePatterns keep only posts whose plain text matches at least one of them,
+	// posts matching ExcludePatterns are left out anyway
+	IncludePatterns []*Pattern
+
+	// AttachmentsOnly keeps only posts with documents attached
+	AttachmentsOnly bool
+
+	// ExcludeForwards leaves posts forwarded from other chats out of the feed
+	ExcludeForwards bool
+
+	// ExcludeAds leaves posts marked as ads out of the feed
+	ExcludeAds bool
+
+	// MinViews leaves posts with fewer views out of the feed
+	MinViews int
+
+	// ShowStats adds views and reactions to the end of each post
+	ShowStats bool
+
+	// Authors keeps only posts signed by one of these authors, matched case-insensitively
+	Authors []string
+
+	// Tags keeps only posts with at least one of these hashtags, matched case-insensitively
+	Tags []string
+
+	// Query makes a feed of the channel search results at t.me
+	Query string
+
+	// Filter keeps only posts matching the filter expression
+	// A nil filter keeps all posts
+	Filter *Filter
+
+	// CacheTTL is the cache time-to-live in minutes
+	// A value of 0 means no caching
+	CacheTTL int
+
+	// Depth is the number of channel pages to fetch following t.me pagination
+	Depth int
+
+	// Limit is the maximum number of posts in the feed
+	// A value of 0 means no limit
+	Limit int
+
+	// MaxAge is the maximum age of posts in the feed in hours
+	// A value of 0 means no limit
+	MaxAge int
+}
+
+// PostParams represents validated request parameters for a single post
+type PostParams struct {
+	// Username is the Telegram channel username
+	Username string
+
+	// PostID is the ID of the post in the channel
+	PostID int
+
+	// Format is either "atom" or "rss" for a one-item feed,
+	// "json" for a JSON object or "html" for a standalone HTML page
+	Format string
+
 	// CacheTTL is the cache time-to-live in minutes
 	// A value of 0 means no caching
 	CacheTTL int
 }
 
+// ScrapeParams controls how deep into the channel history the scraper goes
+type ScrapeParams struct {
+	// Depth is the maximum number of pages to fetch
+	Depth int
+
+	// Limit stops the pagination once this many posts are collected
+	// A value of 0 means no limit
+	Limit int
+
+	// MaxAge stops the pagination once posts get older than this
+	// A value of 0 means no limit
+	MaxAge time.Duration
+
+	// Query searches the channel history with t.me search instead of fetching the latest posts
+	Query string
+}
+
+// ScrapeParams returns the part of the feed params that affects scraping
+func (p *FeedParams) ScrapeParams() ScrapeParams {
+	params := ScrapeParams{
+		Depth:  p.Depth,
+		Limit:  p.Limit,
+		MaxAge: time.Duration(p.MaxAge) * time.Hour,
+	}
+
+	// A single hashtag is searched for at t.me to go beyond the latest posts,
+	// posts with any of several hashtags are picked from the latest ones.
+	// An explicit query wins, the hashtags are picked from its results.
+	if p.Query != "" {
+		params.Query = p.Query
+	} else if len(p.Tags) == 1 {
+		params.Query = "#" + p.Tags[0]
+	}
+
+	return params
+}
+
 // NewFeedParamFromRequest parses and validates request parameters and creates a new FeedParams
 // nolint: cyclop
 func NewFeedParamFromRequest(r *http.Request) (*FeedParams, error) {
@@ -48,53 +170,78 @@ func NewFeedParamFromRequest(r *http.Request) (*FeedParams, error) {
 
 	if format == "" {
 		format = FormatRSS
-	} else if format != FormatRSS && format != FormatAtom {
-		return nil, fmt.Errorf("format must be %s or %s", FormatRSS, FormatAtom)
+	} else if format != FormatRSS && format != FormatAtom && format != FormatPodcast && format != FormatJSON {
+		return nil, fmt.Errorf("format must be %s, %s, %s or %s", FormatRSS, FormatAtom, FormatPodcast, FormatJSON)
 	}
 
-	var excludeWords []string
+	excludeWords := parseList(qp, "exclude")
+	includeWords := parseList(qp, "include")
+	authors := parseList(qp, "author")
+	tags := parseTags(qp, "tag")
+	query := strings.TrimSpace(qp.Get("q"))
+
+	if utf8.RuneCountInString(query) > QueryMaxLength {
+		return nil, fmt.Errorf("q must be at most %d characters long", QueryMaxLength)
+	}
 
-	if exclude := qp.Get("exclude"); exclude != "" {
-		excludeWords = strings.Split(exclude, "|")
+	excludeCaseSensitive := parseBool(qp, "exclude_case_sensitive")
+	includeCaseSensitive := parseBool(qp, "include_case_sensitive")
+	attachmentsOnly := parseBool(qp, "attachments_only")
+	excludeForwards := parseBool(qp, "exclude_forwards")
+	excludeAds := parseBool(qp, "exclude_ads")
+	showStats := parseBool(qp, "show_stats")
 
-		for i, word := range excludeWords {
-			excludeWords[i] = strings.TrimSpace(word)
-		}
+	// Parse cache TTL with default
+	cacheTTL, err := parseNonNegativeInt(qp, "cache_ttl", CacheTTLDefault)
+
+	if err != nil {
+		return nil, err
+	}
 
-		// Filter out empty strings
-		filtered := make([]string, 0, len(excludeWords))
+	depth, err := parseNonNegativeInt(qp, "depth", DepthDefault)
 
-		for _, word := range excludeWords {
-			if word != "" {
-				filtered = append(filtered, word)
-			}
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		excludeWords = filtered
+	if depth < 1 || depth > DepthMax {
+		return nil, fmt.Errorf("depth must be between 1 and %d", DepthMax)
 	}
 
-	excludeCaseSensitive := false
+	limit, err := parseNonNegativeInt(qp, "limit", 0)
 
-	if caseSensitive := qp.Get("exclude_case_sensitive"); caseSensitive != "" {
-		if caseSensitive == "1" || strings.EqualFold(caseSensitive, "true") {
-			excludeCaseSensitive = true
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse cache TTL with default
-	cacheTTL := CacheTTLDefault
+	maxAge, err := parseNonNegativeInt(qp, "max_age", 0)
 
-	if ttlStr := qp.Get("cache_ttl"); ttlStr != "" {
-		var err error
-		cacheTTL, err = strconv.Atoi(ttlStr)
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			return nil, fmt.Errorf("cache_ttl must be a valid integer")
-		}
+	minViews, err := parseNonNegativeInt(qp, "min_views", 0)
 
-		if cacheTTL < 0 {
-			return nil, fmt.Errorf("cache_ttl must be non-negative")
-		}
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := ParseFilter(qp.Get("filter"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	excludePatterns, err := parsePatterns(qp, "exclude_re")
+
+	if err != nil {
+		return nil, err
+	}
+
+	includePatterns, err := parsePatterns(qp, "include_re")
+
+	if err != nil {
+		return nil, err
 	}
 
 	return &FeedParams{
@@ -102,6 +249,146 @@ func NewFeedParamFromRequest(r *http.Request) (*FeedParams, error) {
 		Format:               format,
 		ExcludeWords:         excludeWords,
 		ExcludeCaseSensitive: excludeCaseSensitive,
+		IncludeWords:         includeWords,
+		IncludeCaseSensitive: includeCaseSensitive,
+		ExcludePatterns:      excludePatterns,
+		IncludePatterns:      includePatterns,
+		AttachmentsOnly:      attachmentsOnly,
+		ExcludeForwards:      excludeForwards,
+		ExcludeAds:           excludeAds,
+		MinViews:             minViews,
+		ShowStats:            showStats,
+		Authors:              authors,
+		Tags:                 tags,
+		Query:                query,
+		Filter:               filter,
 		CacheTTL:             cacheTTL,
+		Depth:                depth,
+		Limit:                limit,
+		MaxAge:               maxAge,
 	}, nil
 }
+
+// NewPostParamsFromRequest parses and validates request parameters and creates a new PostParams
+func NewPostParamsFromRequest(r *http.Request) (*PostParams, error) {
+	username := r.PathValue("username")
+
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	postID, err := strconv.Atoi(r.PathValue("postID"))
+
+	if err != nil || postID <= 0 {
+		return nil, fmt.Errorf("postID must be a positive integer")
+	}
+
+	qp := r.URL.Query()
+
+	format := qp.Get("format")
+
+	if format == "" {
+		format = FormatRSS
+	} else if format != FormatRSS && format != FormatAtom && format != FormatJSON && format != FormatHTML {
+		return nil, fmt.Errorf("format must be %s, %s, %s or %s", FormatRSS, FormatAtom, FormatJSON, FormatHTML)
+	}
+
+	cacheTTL, err := parseNonNegativeInt(qp, "cache_ttl", CacheTTLDefault)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostParams{
+		Username: username,
+		PostID:   postID,
+		Format:   format,
+		CacheTTL: cacheTTL,
+	}, nil
+}
+
+// parseList parses an optional list query parameter with items separated by "|"
+func parseList(qp url.Values, name string) []string {
+	value := qp.Get(name)
+
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, "|")
+
+	// Filter out empty strings
+	filtered := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered
+}
+
+// parseTags parses an optional list of hashtags with or without the leading "#"
+func parseTags(qp url.Values, name string) []string {
+	tags := parseList(qp, name)
+
+	for i, tag := range tags {
+		tags[i] = strings.TrimPrefix(tag, "#")
+	}
+
+	return slices.DeleteFunc(tags, func(tag string) bool { return tag == "" })
+}
+
+// parsePatterns parses an optional query parameter with RE2 patterns, which may be repeated
+func parsePatterns(qp url.Values, name string) ([]*Pattern, error) {
+	var patterns []*Pattern
+
+	for _, value := range qp[name] {
+		if value == "" {
+			continue
+		}
+
+		if len(patterns) == PatternsMax {
+			return nil, fmt.Errorf("%s must not be given more than %d times", name, PatternsMax)
+		}
+
+		pattern, err := ParsePattern(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// parseBool parses an optional boolean query parameter, "1" or "true" mean true
+func parseBool(qp url.Values, name string) bool {
+	value := qp.Get(name)
+
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+// parseNonNegativeInt parses an optional non-negative integer query parameter
+func parseNonNegativeInt(qp url.Values, name string, defaultValue int) (int, error) {
+	str := qp.Get(name)
+
+	if str == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(str)
+
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a valid integer", name)
+	}
+
+	if value < 0 {
+		return 0, fmt.Errorf("%s must be non-negative", name)
+	}
+
+	return value, nil
+}
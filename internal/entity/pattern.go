@@ -0,0 +1,185 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// PatternMaxLength limits the length of a pattern in characters
+	PatternMaxLength = 200
+
+	// PatternMaxInstructions limits the size of a compiled pattern,
+	// which grows with repetitions like (a{50}){20}
+	PatternMaxInstructions = 2000
+
+	// PatternsMax limits the number of patterns per parameter
+	PatternsMax = 10
+)
+
+// Pattern is an RE2 regular expression where \b and \B treat letters
+// of any script as word characters, not only ASCII ones, so \bкот\b works as expected
+type Pattern struct {
+	source string
+	re     *regexp.Regexp
+	// Whether the pattern matches a text with marked characters
+	marked bool
+}
+
+// Markers surround every character of a text, so that RE2 checking \b against ASCII
+// finds word boundaries where the characters are, and ^ and $ still see newlines
+const (
+	wordMarker    = 'w'
+	nonWordMarker = '.'
+	newlineMarker = '\n'
+)
+
+// ParsePattern compiles an RE2 pattern checking its length and complexity
+func ParsePattern(source string) (*Pattern, error) {
+	if utf8.RuneCountInString(source) > PatternMaxLength {
+		return nil, fmt.Errorf("pattern is longer than %d characters", PatternMaxLength)
+	}
+
+	tree, err := syntax.Parse(source, syntax.Perl)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	prog, err := syntax.Compile(tree.Simplify())
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	if len(prog.Inst) > PatternMaxInstructions {
+		return nil, fmt.Errorf("pattern is too complex")
+	}
+
+	p := &Pattern{source: source, marked: hasBoundaries(tree)}
+
+	// Characters of the pattern match marked ones of the text, while \b, \B and the anchors
+	// stay zero-width assertions, which RE2 checks against the markers around them
+	if p.marked {
+		tree = &syntax.Regexp{Op: syntax.OpConcat, Sub: []*syntax.Regexp{
+			{Op: syntax.OpBeginText},
+			// The match may start at any character, but not between a marker and its character
+			{Op: syntax.OpStar, Flags: syntax.NonGreedy, Sub: []*syntax.Regexp{
+				markCharacter(&syntax.Regexp{Op: syntax.OpAnyChar}),
+			}},
+			markBoundaries(tree),
+		}}
+	}
+
+	if p.re, err = regexp.Compile(tree.String()); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	return p, nil
+}
+
+func hasBoundaries(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpWordBoundary || re.Op == syntax.OpNoWordBoundary {
+		return true
+	}
+
+	for _, sub := range re.Sub {
+		if hasBoundaries(sub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// markBoundaries rewrites the pattern to match a text marked with markText
+func markBoundaries(re *syntax.Regexp) *syntax.Regexp {
+	switch re.Op {
+	case syntax.OpLiteral:
+		sub := make([]*syntax.Regexp, 0, len(re.Rune))
+
+		for _, r := range re.Rune {
+			sub = append(sub, markCharacter(&syntax.Regexp{Op: syntax.OpLiteral, Rune: []rune{r}, Flags: re.Flags}))
+		}
+
+		return &syntax.Regexp{Op: syntax.OpConcat, Sub: sub}
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return markCharacter(re)
+	}
+
+	marked := *re
+	marked.Sub = make([]*syntax.Regexp, len(re.Sub))
+
+	for i, s := range re.Sub {
+		marked.Sub[i] = markBoundaries(s)
+	}
+
+	return &marked
+}
+
+// markCharacter wraps the pattern of a single character with the markers around it
+func markCharacter(re *syntax.Regexp) *syntax.Regexp {
+	marker := func() *syntax.Regexp {
+		return &syntax.Regexp{Op: syntax.OpCharClass, Rune: []rune{
+			newlineMarker, newlineMarker, nonWordMarker, nonWordMarker, wordMarker, wordMarker,
+		}}
+	}
+
+	return &syntax.Regexp{Op: syntax.OpConcat, Sub: []*syntax.Regexp{marker(), re, marker()}}
+}
+
+// markText surrounds every character of the text with markers of its kind
+func markText(text string) string {
+	var b strings.Builder
+
+	b.Grow(len(text) * 3)
+
+	for _, r := range text {
+		marker := nonWordMarker
+
+		if isWordRune(r) {
+			marker = wordMarker
+		} else if r == '\n' {
+			marker = newlineMarker
+		}
+
+		b.WriteRune(marker)
+		b.WriteRune(r)
+		b.WriteRune(marker)
+	}
+
+	return b.String()
+}
+
+// MatchString checks if the text contains a match of the pattern
+func (p *Pattern) MatchString(text string) bool {
+	if p.marked {
+		text = markText(text)
+	}
+
+	return p.re.MatchString(text)
+}
+
+// String returns the source of the pattern
+func (p *Pattern) String() string {
+	return p.source
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// MatchAnyPattern checks if the text matches at least one of the patterns
+func MatchAnyPattern(text string, patterns []*Pattern) bool {
+	for _, p := range patterns {
+		if p.MatchString(text) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package entity_test
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nDmitry/tgfeed/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		text     string
+		expected bool
+	}{
+		{pattern: `^Срочно`, text: "Срочно: новости", expected: true},
+		{pattern: `^Срочно`, text: "Не срочно", expected: false},
+		{pattern: `(?i)\bкот\b`, text: "Мой Кот спит", expected: true},
+		{pattern: `\bкот\b`, text: "котлета", expected: false},
+		{pattern: `\bкот\b`, text: "котлета и кот", expected: true},
+		{pattern: `\bкот`, text: "экспорт", expected: false},
+		{pattern: `\bкот\b\s+\bспит\b`, text: "кот спит", expected: true},
+		{pattern: `\bрекламн\p{L}*`, text: "Рекламный пост, рекламная интеграция", expected: true},
+		{pattern: `(?i)\bрекламн\p{L}*`, text: "Рекламный пост", expected: true},
+		{pattern: `\Bот\b`, text: "кот", expected: true},
+		{pattern: `\Bот\b`, text: "от", expected: false},
+		{pattern: `\bcat\b|собак`, text: "собака", expected: true},
+		{pattern: `\bcat\b`, text: "concatenate", expected: false},
+		{pattern: `\bcat\b`, text: "a cat!", expected: true},
+		// Matches failing a boundary don't hide other starts and lengths
+		{pattern: `\bкот\b.*`, text: "котлета, кот", expected: true},
+		{pattern: `.*\bкот\b`, text: "кот котлета", expected: true},
+		{pattern: `(?s)\bреклама\b.{0,20}\bинн\b`, text: "рекламация. Реклама. ИНН 123", expected: false},
+		{pattern: `(?is)\bреклама\b.{0,20}\bинн\b`, text: "рекламация. Реклама. ИНН 123", expected: true},
+		{pattern: `\bкот\b$`, text: "котлета и кот", expected: true},
+		{pattern: `(?m)\bкот$`, text: "кот\nпёс", expected: true},
+		{pattern: `^\b\bкот`, text: "кот", expected: true},
+		{pattern: `\b\Bкот`, text: "кот", expected: false},
+		{pattern: `[^а]от`, text: "кот", expected: true},
+		{pattern: `\B`, text: "", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" in "+tt.text, func(t *testing.T) {
+			pattern, err := entity.ParsePattern(tt.pattern)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, pattern.MatchString(tt.text))
+			assert.Equal(t, tt.pattern, pattern.String())
+		})
+	}
+}
+
+// On ASCII texts, where Go's \b is right, patterns must match the same as with regexp
+func TestParsePattern_MatchLikeRegexp(t *testing.T) {
+	patterns := []string{
+		`(?i)\b(?:super)?\b\w+`,
+		`\b(?:x)?\bfoo`,
+		`\B^`,
+		`$\B`,
+		`\B^$$`,
+		`\ba*a*\b\b(?s).`,
+		`(?m)^\b\w+\b$`,
+		`\b\z`,
+		`\A\B`,
+	}
+
+	texts := []string{"", "cat", "foo", ". ", "\nba\n", "ab", "a b", "_a\n\nb."}
+
+	// Random patterns and texts made of the pieces that affect boundaries
+	atoms := []string{"a", "b", " ", ".", `\w`, `\W`, "[ab]", `\n`, `\b`, `\B`, "^", "$", `\A`, `\z`}
+	suffixes := []string{"", "", "*", "?", "+"}
+	flags := []string{"", "(?m)", "(?s)", "(?i)", "(?ms)"}
+	chars := []string{"a", "b", "A", "_", "1", " ", ".", "\n"}
+	rnd := rand.New(rand.NewSource(1)) // nolint: gosec
+
+	randomPattern := func() string {
+		var b strings.Builder
+
+		b.WriteString(flags[rnd.Intn(len(flags))])
+
+		for range 1 + rnd.Intn(6) {
+			atom := atoms[rnd.Intn(len(atoms))]
+
+			if rnd.Intn(4) == 0 {
+				atom = "(?:" + atom + "|" + atoms[rnd.Intn(len(atoms))] + ")"
+			}
+
+			b.WriteString(atom + suffixes[rnd.Intn(len(suffixes))])
+		}
+
+		return b.String()
+	}
+
+	for range 2000 {
+		patterns = append(patterns, randomPattern())
+	}
+
+	for range 50 {
+		var b strings.Builder
+
+		for range rnd.Intn(8) {
+			b.WriteString(chars[rnd.Intn(len(chars))])
+		}
+
+		texts = append(texts, b.String())
+	}
+
+	for _, source := range patterns {
+		re, err := regexp.Compile(source)
+
+		if err != nil {
+			continue
+		}
+
+		pattern, err := entity.ParsePattern(source)
+		require.NoError(t, err, source)
+
+		for _, text := range texts {
+			assert.Equal(t, re.MatchString(text), pattern.MatchString(text), "%q in %q", source, text)
+		}
+	}
+}
+
+func TestParsePattern_Errors(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		expectedErr string
+	}{
+		{pattern: `(unclosed`, expectedErr: "invalid pattern"},
+		{pattern: `(?<=lookbehind)`, expectedErr: "invalid pattern"},
+		{pattern: strings.Repeat("a", entity.PatternMaxLength+1), expectedErr: "longer than"},
+		{pattern: `(\p{L}|\d|_){1000}`, expectedErr: "too complex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := entity.ParsePattern(tt.pattern)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
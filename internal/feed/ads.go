@@ -0,0 +1,109 @@
+package feed
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nDmitry/tgfeed/internal/app"
+	"github.com/nDmitry/tgfeed/internal/entity"
+)
+
+// DefaultAdMarkers are RE2 patterns of the marks Russian law requires on ads
+// and channels commonly use: the #реклама hashtag, erid tokens of registered ads
+// and "Реклама. ООО «…», ИНН …" footers
+var DefaultAdMarkers = []string{
+	`(?i)#(реклама|ad|ads|sponsored|промо|партнерскийпост|партнёрскийпост)\b`,
+	`(?i)\berid\b\s*[:=]?\s*[0-9a-z]{5,}`,
+	`(?is)\bреклама\b.{0,300}\bинн\b\s*:?\s*\d{10,12}\b`,
+}
+
+// AdClassifier flags sponsored posts by their markers
+type AdClassifier struct {
+	markers []*entity.Pattern
+}
+
+var defaultAdClassifier = mustNewAdClassifier(DefaultAdMarkers)
+
+// NewAdClassifier compiles the marker patterns
+func NewAdClassifier(markers []string) (*AdClassifier, error) {
+	c := &AdClassifier{markers: make([]*entity.Pattern, 0, len(markers))}
+
+	for _, m := range markers {
+		pattern, err := entity.ParsePattern(m)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid ad marker %q: %w", m, err)
+		}
+
+		c.markers = append(c.markers, pattern)
+	}
+
+	return c, nil
+}
+
+func mustNewAdClassifier(markers []string) *AdClassifier {
+	c, err := NewAdClassifier(markers)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// newAdClassifierFromEnv uses markers from the file at AD_MARKERS_FILE,
+// one pattern per line, falling back to the default ones
+func newAdClassifierFromEnv() *AdClassifier {
+	path := os.Getenv("AD_MARKERS_FILE")
+
+	if path == "" {
+		return defaultAdClassifier
+	}
+
+	c, err := NewAdClassifierFromFile(path)
+
+	if err != nil {
+		app.Logger().Error("Could not use AD_MARKERS_FILE, falling back to the default ad markers", "error", err)
+		return defaultAdClassifier
+	}
+
+	return c
+}
+
+// NewAdClassifierFromFile reads marker patterns from a file skipping empty lines and # comments
+func NewAdClassifierFromFile(path string) (*AdClassifier, error) {
+	content, err := os.ReadFile(path) // nolint: gosec
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read ad markers: %w", err)
+	}
+
+	var markers []string
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+
+		// A line starting with "#" followed by a space is a comment, "#реклама" is a marker
+		if line == "" || line == "#" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+
+		markers = append(markers, line)
+	}
+
+	return NewAdClassifier(markers)
+}
+
+// IsSponsored checks the text and the links of the post for ad markers,
+// a nil classifier uses the default markers
+func (c *AdClassifier) IsSponsored(p entity.Post) bool {
+	if c == nil {
+		c = defaultAdClassifier
+	}
+
+	target := filterTarget(p)
+	text := target.Text + "\n" + strings.Join(target.Links, "\n")
+
+	return entity.MatchAnyPattern(text, c.markers)
+}
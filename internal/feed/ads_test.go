@@ -0,0 +1,98 @@
+package feed_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nDmitry/tgfeed/internal/entity"
+	"github.com/nDmitry/tgfeed/internal/feed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdClassifier_IsSponsored(t *testing.T) {
+	tests := []struct {
+		name     string
+		post     entity.Post
+		expected bool
+	}{
+		{
+			name:     "Ad hashtag",
+			post:     entity.Post{ContentHTML: `Лучший сервис<br><a href="?q=%23реклама">#реклама</a>`},
+			expected: true,
+		},
+		{
+			name:     "Erid token in the text",
+			post:     entity.Post{ContentHTML: `Скидки до 50%<br>erid: 2VtzqwXkB7r`},
+			expected: true,
+		},
+		{
+			name:     "Erid token in a link",
+			post:     entity.Post{ContentHTML: `<a href="https://example.com/promo?erid=LjN8KUvJ3">Подробнее</a>`},
+			expected: true,
+		},
+		{
+			name:     "Law-required footer",
+			post:     entity.Post{ContentHTML: `Курс по Go<br><br>Реклама. ООО «Школа», ИНН 7701234567`},
+			expected: true,
+		},
+		{
+			name:     "Law-required footer after a word containing the marker",
+			post:     entity.Post{ContentHTML: `Антиреклама не работает<br><br>Реклама. ООО «Школа», ИНН 7701234567`},
+			expected: true,
+		},
+		{
+			name:     "Ad marker in the link preview",
+			post:     entity.Post{LinkPreview: &entity.LinkPreview{Description: "Реклама. ИП Иванов, ИНН 770123456789"}},
+			expected: true,
+		},
+		{
+			name:     "Regular post mentioning ads",
+			post:     entity.Post{ContentHTML: `Рынок рекламы вырос на 20%, а Meridian выпустил отчет`},
+			expected: false,
+		},
+		{
+			name:     "Hashtag starting like the ad one",
+			post:     entity.Post{ContentHTML: `#рекламарынок`},
+			expected: false,
+		},
+	}
+
+	var classifier *feed.AdClassifier // uses the default markers
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifier.IsSponsored(tt.post))
+		})
+	}
+}
+
+func TestNewAdClassifier(t *testing.T) {
+	classifier, err := feed.NewAdClassifier([]string{`(?i)\bпартнерский материал\b`})
+	require.NoError(t, err)
+
+	assert.True(t, classifier.IsSponsored(entity.Post{ContentHTML: "Партнерский материал"}))
+	assert.False(t, classifier.IsSponsored(entity.Post{ContentHTML: "#реклама"}), "Custom markers replace the default ones")
+
+	_, err = feed.NewAdClassifier([]string{`(unclosed`})
+	require.Error(t, err)
+}
+
+func TestNewAdClassifierFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ads.txt")
+	content := "# Custom ad markers\n\n(?i)#партнерскийматериал\\b\n  #реклама  \n"
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	classifier, err := feed.NewAdClassifierFromFile(path)
+	require.NoError(t, err)
+
+	assert.True(t, classifier.IsSponsored(entity.Post{ContentHTML: "#ПартнерскийМатериал"}))
+	assert.True(t, classifier.IsSponsored(entity.Post{ContentHTML: "#реклама"}))
+	assert.False(t, classifier.IsSponsored(entity.Post{ContentHTML: "erid: 2VtzqwXkB7r"}), "Custom markers replace the default ones")
+	assert.False(t, classifier.IsSponsored(entity.Post{ContentHTML: "# Custom ad markers"}), "Comments are not markers")
+
+	_, err = feed.NewAdClassifierFromFile(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
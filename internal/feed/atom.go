@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"encoding/xml"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/feeds"
+	"github.com/nDmitry/tgfeed/internal/entity"
+)
+
+// atomFeedXML embeds the gorilla/feeds Atom feed, its entries are shadowed by the extended ones
+type atomFeedXML struct {
+	XMLName xml.Name `xml:"feed"`
+	*feeds.AtomFeed
+	Entries []*atomEntry `xml:"entry"`
+}
+
+// atomEntry has proper Atom categories, gorilla/feeds renders a category as a text element
+type atomEntry struct {
+	XMLName xml.Name `xml:"entry"`
+	*feeds.AtomEntry
+	Categories []*atomCategory
+}
+
+type atomCategory struct {
+	XMLName xml.Name `xml:"category"`
+	Term    string   `xml:"term,attr"`
+}
+
+// atom converts the feed to Atom with hashtags as categories and an enclosure link for every image.
+// Posts must be in the order of feed items.
+func (g *Generator) atom(feed *feeds.Feed, posts []entity.Post) *atomFeedXML {
+	atom := &atomFeedXML{AtomFeed: (&feeds.Atom{Feed: feed}).AtomFeed()}
+	atom.Entries = make([]*atomEntry, 0, len(atom.AtomFeed.Entries))
+
+	for i, e := range atom.AtomFeed.Entries {
+		entry := &atomEntry{AtomEntry: e}
+
+		for _, img := range posts[i].Images {
+			if slices.ContainsFunc(entry.Links, func(link feeds.AtomLink) bool { return link.Href == img.URL }) {
+				continue
+			}
+
+			entry.Links = append(entry.Links, feeds.AtomLink{
+				Href:   img.URL,
+				Rel:    "enclosure",
+				Type:   img.Type,
+				Length: strconv.FormatInt(img.Size, 10),
+			})
+		}
+
+		for _, tag := range posts[i].Tags {
+			entry.Categories = append(entry.Categories, &atomCategory{Term: tag})
+		}
+
+		atom.Entries = append(atom.Entries, entry)
+	}
+
+	return atom
+}
+
+// FeedXml implements feeds.XmlFeed
+func (a *atomFeedXML) FeedXml() any {
+	return a
+}
@@ -1,11 +1,18 @@
 package feed
 
 import (
+	"fmt"
 	"io"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 
@@ -20,6 +27,9 @@ const (
 	ellipsis        = "…"
 	openParenthesis = '('
 	punctuation     = ",.;:!? "
+
+	// mediaSizeLookupsMax limits concurrent requests to find out media sizes
+	mediaSizeLookupsMax = 8
 )
 
 var (
@@ -27,6 +37,8 @@ var (
 	multipleSpacesRegex = regexp.MustCompile(`\s+`)
 	sentenceEndRegex    = regexp.MustCompile(`[.!?…](?:\s|$)|\.{3}`)
 	imageExtRegex       = regexp.MustCompile(`\.(jpg|jpeg|png|gif)$`)
+	cssWidthRegex       = regexp.MustCompile(`(?:^|;)\s*width:\s*([\d.]+)px`)
+	cssPaddingTopRegex  = regexp.MustCompile(`padding-top:\s*([\d.]+)%`)
 )
 
 // extractTitle extracts a meaningful title from HTML content following the specified rules.
@@ -209,6 +221,30 @@ func truncateAtWordBoundary(text string, limit int) string {
 	return text
 }
 
+// extractChannelInfo gets the channel description, counters and verified status
+func extractChannelInfo(element *colly.HTMLElement, channel *entity.Channel) {
+	channel.Description = strings.TrimSpace(element.DOM.Find(".tgme_channel_info_description").Text())
+	channel.Verified = element.DOM.Find(".tgme_channel_info_header_title .verified-icon").Length() > 0
+
+	element.DOM.Find(".tgme_channel_info_counter").Each(func(_ int, s *goquery.Selection) {
+		value := parseCount(s.Find(".counter_value").Text())
+
+		// Counter types are plural unless the value is 1
+		switch strings.TrimSuffix(strings.TrimSpace(s.Find(".counter_type").Text()), "s") {
+		case "subscriber":
+			channel.Subscribers = value
+		case "photo":
+			channel.Photos = value
+		case "video":
+			channel.Videos = value
+		case "link":
+			channel.Links = value
+		case "file":
+			channel.Files = value
+		}
+	})
+}
+
 // extractImages gets all images from message grouped layer
 func extractImages(element *colly.HTMLElement) []entity.Image {
 	var images []entity.Image
@@ -221,34 +257,305 @@ func extractImages(element *colly.HTMLElement) []entity.Image {
 		}
 
 		imageType := extractImageTypeFromURL(imageURL)
-		imageSize := getImageSize(imageURL)
+		width, height := extractDimensions(el.Attr("style"), el.DOM.Find(".tgme_widget_message_photo").AttrOr("style", ""))
 
 		images = append(images, entity.Image{
-			URL:  imageURL,
-			Type: imageType,
-			Size: imageSize,
+			URL:    imageURL,
+			Type:   imageType,
+			Width:  width,
+			Height: height,
 		})
 	})
 
 	return images
 }
 
-// extractPreview finds an image link preview and extracts it
-func extractPreview(element *colly.HTMLElement) *entity.Image {
-	previewURL, exists := element.DOM.Find(".tgme_widget_message_link_preview").Attr("href")
+// extractVideos gets all playable videos from the message
+func extractVideos(element *colly.HTMLElement) []entity.Video {
+	var videos []entity.Video
+
+	element.DOM.Find(".tgme_widget_message_video_player").Each(func(_ int, s *goquery.Selection) {
+		videoURL := s.Find("video").AttrOr("src", "")
+
+		// Videos that are too big to be played at t.me have no source
+		if videoURL == "" {
+			return
+		}
+
+		thumbStyle := s.Find(".tgme_widget_message_video_thumb").AttrOr("style", "")
+		wrapStyle := s.Find(".tgme_widget_message_video_wrap").AttrOr("style", "")
+		width, height := extractDimensions(wrapStyle, wrapStyle)
+
+		videos = append(videos, entity.Video{
+			URL:          videoURL,
+			Type:         extractVideoTypeFromURL(videoURL),
+			ThumbnailURL: extractImageURLFromStyle(thumbStyle),
+			Duration:     parseDuration(s.Find(".message_video_duration").Text()),
+			Width:        width,
+			Height:       height,
+		})
+	})
+
+	return videos
+}
+
+// extractAudios gets voice messages and audio files from the message
+func extractAudios(element *colly.HTMLElement) []entity.Audio {
+	var audios []entity.Audio
+
+	element.DOM.Find(".tgme_widget_message_voice_player").Each(func(_ int, s *goquery.Selection) {
+		audioURL := s.Find(".tgme_widget_message_voice").AttrOr("src", "")
+
+		if audioURL == "" {
+			return
+		}
+
+		audios = append(audios, entity.Audio{
+			URL:      audioURL,
+			Type:     extractAudioTypeFromURL(audioURL),
+			Title:    "Voice message",
+			Duration: parseDuration(s.Find(".tgme_widget_message_voice_duration").Text()),
+		})
+	})
+
+	// Audio files are displayed as documents with an audio icon,
+	// most of them can't be played at t.me and only have a title and a performer
+	element.DOM.Find(".tgme_widget_message_document_wrap").Each(func(_ int, s *goquery.Selection) {
+		if !s.Find(".tgme_widget_message_document_icon").HasClass("audio") {
+			return
+		}
+
+		audio := entity.Audio{
+			Title:     strings.TrimSpace(s.Find(".tgme_widget_message_document_title").Text()),
+			Performer: strings.TrimSpace(s.Find(".tgme_widget_message_document_extra").Text()),
+		}
+
+		if audioURL := s.Find("audio").AttrOr("src", ""); audioURL != "" {
+			audio.URL = audioURL
+			audio.Type = extractAudioTypeFromURL(audioURL)
+		}
+
+		audios = append(audios, audio)
+	})
+
+	return audios
+}
+
+// extractAttachments gets all documents shared in the message except audio files
+func extractAttachments(element *colly.HTMLElement) []entity.Attachment {
+	var attachments []entity.Attachment
 
-	if exists && imageExtRegex.MatchString(previewURL) {
-		preview := &entity.Image{
-			URL: previewURL,
+	element.DOM.Find(".tgme_widget_message_document_wrap").Each(func(_ int, s *goquery.Selection) {
+		if s.Find(".tgme_widget_message_document_icon").HasClass("audio") {
+			return
 		}
 
-		preview.Type = extractImageTypeFromURL(preview.URL)
-		preview.Size = getImageSize(preview.URL)
+		name := strings.TrimSpace(s.Find(".tgme_widget_message_document_title").Text())
+
+		if name == "" {
+			return
+		}
+
+		attachments = append(attachments, entity.Attachment{
+			Name:      name,
+			SizeText:  strings.TrimSpace(s.Find(".tgme_widget_message_document_extra").Text()),
+			Extension: strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")),
+		})
+	})
+
+	return attachments
+}
+
+// extractPoll gets a poll with its results from the message
+func extractPoll(element *colly.HTMLElement) *entity.Poll {
+	pollEl := element.DOM.Find(".tgme_widget_message_poll").First()
 
-		return preview
+	if pollEl.Length() == 0 {
+		return nil
+	}
+
+	poll := &entity.Poll{
+		Question: strings.TrimSpace(pollEl.Find(".tgme_widget_message_poll_question").Text()),
+		Type:     strings.TrimSpace(pollEl.Find(".tgme_widget_message_poll_type").Text()),
+	}
+
+	pollEl.Find(".tgme_widget_message_poll_option").Each(func(_ int, s *goquery.Selection) {
+		percent := strings.TrimSuffix(strings.TrimSpace(s.Find(".tgme_widget_message_poll_option_percent").Text()), "%")
+
+		option := entity.PollOption{
+			Text: strings.TrimSpace(s.Find(".tgme_widget_message_poll_option_text").Text()),
+		}
+
+		option.Percent, _ = strconv.Atoi(percent)
+
+		poll.Options = append(poll.Options, option)
+	})
+
+	// Voters counter is in the message footer, e.g. "1.2K voters"
+	if voters := strings.Fields(element.DOM.Find(".tgme_widget_message_voters").Text()); len(voters) > 0 {
+		poll.Voters = parseCount(voters[0])
 	}
 
-	return nil
+	return poll
+}
+
+// extractForward gets the source of a forwarded message
+func extractForward(element *colly.HTMLElement) *entity.Forward {
+	fwdEl := element.DOM.Find(".tgme_widget_message_forwarded_from").First()
+
+	if fwdEl.Length() == 0 {
+		return nil
+	}
+
+	nameEl := fwdEl.Find(".tgme_widget_message_forwarded_from_name")
+
+	forward := &entity.Forward{
+		Name: strings.TrimSpace(nameEl.Text()),
+	}
+
+	// Hidden sources have no link
+	href := nameEl.AttrOr("href", "")
+
+	if href == "" {
+		return forward
+	}
+
+	u, err := url.Parse(href)
+
+	if err != nil {
+		return forward
+	}
+
+	// The link is either https://t.me/{username} or https://t.me/{username}/{postID}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	forward.ChannelURL = fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, parts[0])
+
+	if len(parts) > 1 {
+		forward.PostURL = href
+	}
+
+	return forward
+}
+
+// extractReply gets the message a post replies to
+func extractReply(element *colly.HTMLElement) *entity.Reply {
+	replyEl := element.DOM.Find(".tgme_widget_message_reply").First()
+
+	if replyEl.Length() == 0 {
+		return nil
+	}
+
+	return &entity.Reply{
+		Author: strings.TrimSpace(replyEl.Find(".tgme_widget_message_author_name").Text()),
+		Text:   strings.TrimSpace(replyEl.Find(".tgme_widget_message_metatext").Text()),
+		URL:    replyEl.AttrOr("href", ""),
+	}
+}
+
+// extractTags gets hashtags of the message text without the leading "#",
+// t.me links them to the channel search like ?q=%23tag
+func extractTags(element *colly.HTMLElement) []string {
+	var tags []string
+
+	element.DOM.Find(`.tgme_widget_message_text a[href^="?q=%23"]`).Each(func(_ int, s *goquery.Selection) {
+		tag := strings.TrimPrefix(strings.TrimSpace(s.Text()), "#")
+
+		if tag == "" || slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return
+		}
+
+		tags = append(tags, tag)
+	})
+
+	return tags
+}
+
+// extractReactions gets reaction counters of the message
+func extractReactions(element *colly.HTMLElement) []entity.Reaction {
+	var reactions []entity.Reaction
+
+	element.DOM.Find(".tgme_widget_message_reactions .tgme_reaction").Each(func(_ int, s *goquery.Selection) {
+		emoji := strings.TrimSpace(s.Find(".emoji").Text())
+
+		// The counter is the text next to the emoji
+		counter := s.Clone()
+		counter.Find(".emoji, tg-emoji").Remove()
+
+		reactions = append(reactions, entity.Reaction{
+			Emoji: emoji,
+			Count: parseCount(counter.Text()),
+		})
+	})
+
+	return reactions
+}
+
+// isEdited checks whether the message has the "edited" mark next to its date
+func isEdited(element *colly.HTMLElement) bool {
+	if element.DOM.Find(".tgme_widget_message_edited").Length() > 0 {
+		return true
+	}
+
+	// Only the text next to the date is checked, as the meta block also holds the author signature
+	edited := false
+
+	element.DOM.Find(".tgme_widget_message_meta, .tgme_widget_message_date").Contents().Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) != "#text" {
+			return
+		}
+
+		for _, word := range strings.Fields(s.Text()) {
+			if strings.EqualFold(word, "edited") {
+				edited = true
+			}
+		}
+	})
+
+	return edited
+}
+
+// extractLinkPreview gets a link preview card from the message
+func extractLinkPreview(element *colly.HTMLElement) *entity.LinkPreview {
+	previewEl := element.DOM.Find(".tgme_widget_message_link_preview").First()
+
+	if previewEl.Length() == 0 {
+		return nil
+	}
+
+	// Large images go above the title and small ones to the right of it
+	imageStyle := previewEl.Find(".link_preview_image, .link_preview_right_image").First().AttrOr("style", "")
+
+	return &entity.LinkPreview{
+		URL:         previewEl.AttrOr("href", ""),
+		SiteName:    strings.TrimSpace(previewEl.Find(".link_preview_site_name").Text()),
+		Title:       strings.TrimSpace(previewEl.Find(".link_preview_title").Text()),
+		Description: strings.TrimSpace(previewEl.Find(".link_preview_description").Text()),
+		ImageURL:    extractImageURLFromStyle(imageStyle),
+	}
+}
+
+// extractPreview gets an image of a link preview,
+// or the link itself if it points to an image
+func extractPreview(linkPreview *entity.LinkPreview) *entity.Image {
+	if linkPreview == nil {
+		return nil
+	}
+
+	imageURL := linkPreview.ImageURL
+
+	if imageURL == "" && imageExtRegex.MatchString(linkPreview.URL) {
+		imageURL = linkPreview.URL
+	}
+
+	if imageURL == "" {
+		return nil
+	}
+
+	return &entity.Image{
+		URL:  imageURL,
+		Type: extractImageTypeFromURL(imageURL),
+	}
 }
 
 func extractImageURLFromStyle(style string) string {
@@ -275,17 +582,225 @@ func extractImageURLFromStyle(style string) string {
 	return url
 }
 
-func extractImageTypeFromURL(url string) string {
-	switch filepath.Ext(url) {
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
+// extractDimensions gets the media size from the CSS t.me lays it out with:
+// the width in pixels and the aspect ratio as the top padding in percent
+func extractDimensions(widthStyle, ratioStyle string) (int, int) {
+	widthMatch := cssWidthRegex.FindStringSubmatch(widthStyle)
+	ratioMatch := cssPaddingTopRegex.FindStringSubmatch(ratioStyle)
+
+	if widthMatch == nil || ratioMatch == nil {
+		return 0, 0
+	}
+
+	width, err := strconv.ParseFloat(widthMatch[1], 64)
+
+	if err != nil {
+		return 0, 0
+	}
+
+	ratio, err := strconv.ParseFloat(ratioMatch[1], 64)
+
+	if err != nil {
+		return 0, 0
+	}
+
+	return int(math.Round(width)), int(math.Round(width * ratio / 100))
+}
+
+func extractImageTypeFromURL(imageURL string) string {
+	switch extractExtFromURL(imageURL) {
 	case ".png":
 		return "image/png"
 	case ".gif":
 		return "image/gif"
 	default:
-		return "" // Skip unsupported image types
+		return "image/jpeg" // Telegram serves photos and extensionless previews as JPEG
+	}
+}
+
+func extractVideoTypeFromURL(videoURL string) string {
+	switch extractExtFromURL(videoURL) {
+	case ".webm":
+		return "video/webm"
+	case ".mov":
+		return "video/quicktime"
+	default:
+		return "video/mp4" // Telegram transcodes most videos to MP4
+	}
+}
+
+func extractAudioTypeFromURL(audioURL string) string {
+	switch extractExtFromURL(audioURL) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a":
+		return "audio/mp4"
+	default:
+		return "audio/ogg" // Voice messages are OGG/Opus
+	}
+}
+
+// extractExtFromURL returns the file extension ignoring the query string,
+// as Telegram CDN links carry an access token in it
+func extractExtFromURL(fileURL string) string {
+	if u, err := url.Parse(fileURL); err == nil {
+		return filepath.Ext(u.Path)
+	}
+
+	return filepath.Ext(fileURL)
+}
+
+// parseDuration converts durations like "0:38" or "1:02:03" to seconds
+func parseDuration(text string) int {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return 0
+	}
+
+	seconds := 0
+
+	for _, part := range strings.Split(text, ":") {
+		n, err := strconv.Atoi(part)
+
+		if err != nil {
+			return 0
+		}
+
+		seconds = seconds*60 + n
+	}
+
+	return seconds
+}
+
+// parseCount converts abbreviated counters like "950", "1.2K" or "3M" to numbers
+func parseCount(text string) int {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return 0
+	}
+
+	multiplier := 1.0
+
+	switch text[len(text)-1] {
+	case 'K', 'k':
+		multiplier = 1e3
+	case 'M', 'm':
+		multiplier = 1e6
+	case 'B', 'b':
+		multiplier = 1e9
+	}
+
+	if multiplier > 1 {
+		text = text[:len(text)-1]
+	}
+
+	n, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", "."), 64)
+
+	if err != nil {
+		return 0
 	}
+
+	return int(n * multiplier)
+}
+
+// fillMediaSizes looks up the sizes of images, videos and audios of the posts.
+// Images have to be downloaded for that, so the lookups run concurrently
+// to keep deep scrapes with hundreds of them within the request timeout
+func fillMediaSizes(posts []entity.Post) {
+	// Lookups by URL, so images shown both as a preview and in the post are downloaded once
+	lookups := make(map[string]func(string) int64)
+
+	for _, p := range posts {
+		for _, img := range p.Images {
+			lookups[img.URL] = getImageSize
+		}
+
+		if p.Preview != nil {
+			lookups[p.Preview.URL] = getImageSize
+		}
+
+		for _, v := range p.Videos {
+			lookups[v.URL] = getContentLength
+		}
+
+		for _, a := range p.Audios {
+			if a.URL != "" {
+				lookups[a.URL] = getContentLength
+			}
+		}
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sizes = make(map[string]int64, len(lookups))
+		sem   = make(chan struct{}, mediaSizeLookupsMax)
+	)
+
+	for fileURL, lookup := range lookups {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			size := lookup(fileURL)
+
+			mu.Lock()
+			sizes[fileURL] = size
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range posts {
+		p := &posts[i]
+
+		for j := range p.Images {
+			p.Images[j].Size = sizes[p.Images[j].URL]
+		}
+
+		if p.Preview != nil {
+			p.Preview.Size = sizes[p.Preview.URL]
+		}
+
+		for j := range p.Videos {
+			p.Videos[j].Size = sizes[p.Videos[j].URL]
+		}
+
+		for j := range p.Audios {
+			p.Audios[j].Size = sizes[p.Audios[j].URL]
+		}
+	}
+}
+
+// getContentLength gets the size of a remote file without downloading it
+func getContentLength(fileURL string) int64 {
+	logger := app.Logger()
+	// nolint: gosec
+	res, err := httpClient.Head(fileURL)
+
+	if err != nil {
+		logger.Error("Could not get a file size",
+			"url", fileURL,
+			"error", err)
+
+		return 0
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || res.ContentLength < 0 {
+		return 0
+	}
+
+	return res.ContentLength
 }
 
 func getImageSize(imageURL string) int64 {
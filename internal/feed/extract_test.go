@@ -1,11 +1,15 @@
 package feed
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
+	"github.com/nDmitry/tgfeed/internal/entity"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -145,3 +149,367 @@ func TestExtractImageURLFromStyle(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractVideos(t *testing.T) {
+	html := `<div class="tgme_widget_message">
+		<a class="tgme_widget_message_video_player" href="https://t.me/testch/1">
+			<i class="tgme_widget_message_video_thumb" style="background-image:url('https://example.com/thumb.jpg')"></i>
+			<div class="tgme_widget_message_video_wrap" style="width:400px;padding-top:56.25%"><video class="tgme_widget_message_video" src="https://cdn.example.com/video.mp4?token=abc"></video></div>
+			<time class="message_video_duration">1:05</time>
+		</a>
+		<a class="tgme_widget_message_video_player not_supported" href="https://t.me/testch/1">
+			<i class="tgme_widget_message_video_thumb" style="background-image:url('https://example.com/thumb2.jpg')"></i>
+			<time class="message_video_duration">10:00</time>
+		</a>
+	</div>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	videos := extractVideos(&colly.HTMLElement{DOM: doc.Selection})
+
+	require.Len(t, videos, 1, "Videos without a source should be skipped")
+	assert.Equal(t, "https://cdn.example.com/video.mp4?token=abc", videos[0].URL)
+	assert.Equal(t, "video/mp4", videos[0].Type)
+	assert.Equal(t, "https://example.com/thumb.jpg", videos[0].ThumbnailURL)
+	assert.Equal(t, 65, videos[0].Duration)
+	assert.Equal(t, 400, videos[0].Width)
+	assert.Equal(t, 225, videos[0].Height)
+}
+
+func TestExtractDimensions(t *testing.T) {
+	width, height := extractDimensions("width:453px;background-image:url('https://example.com/1.jpg')", "padding-top:75%")
+	assert.Equal(t, 453, width)
+	assert.Equal(t, 340, height)
+
+	width, height = extractDimensions("max-width:453px", "padding-top:75%")
+	assert.Zero(t, width)
+	assert.Zero(t, height)
+
+	width, height = extractDimensions("width:453px", "")
+	assert.Zero(t, width)
+	assert.Zero(t, height)
+}
+
+func TestExtractDocuments(t *testing.T) {
+	html := `<div class="tgme_widget_message">
+		<a class="tgme_widget_message_document_wrap" href="https://t.me/testch/1">
+			<div class="tgme_widget_message_document_icon accent_bg"></div>
+			<div class="tgme_widget_message_document">
+				<div class="tgme_widget_message_document_title">Annual Report.PDF</div>
+				<div class="tgme_widget_message_document_extra">1.2 MB</div>
+			</div>
+		</a>
+		<a class="tgme_widget_message_document_wrap" href="https://t.me/testch/1">
+			<div class="tgme_widget_message_document_icon accent_bg audio"></div>
+			<div class="tgme_widget_message_document">
+				<div class="tgme_widget_message_document_title">Song</div>
+				<div class="tgme_widget_message_document_extra">Performer</div>
+			</div>
+		</a>
+	</div>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	element := &colly.HTMLElement{DOM: doc.Selection}
+
+	attachments := extractAttachments(element)
+	require.Len(t, attachments, 1, "Audio files should not be attachments")
+	assert.Equal(t, "Annual Report.PDF", attachments[0].Name)
+	assert.Equal(t, "1.2 MB", attachments[0].SizeText)
+	assert.Equal(t, "pdf", attachments[0].Extension)
+
+	audios := extractAudios(element)
+	require.Len(t, audios, 1)
+	assert.Equal(t, "Song", audios[0].Title)
+	assert.Equal(t, "Performer", audios[0].Performer)
+	assert.Empty(t, audios[0].URL)
+}
+
+func TestExtractPoll(t *testing.T) {
+	html := `<div class="tgme_widget_message">
+		<div class="tgme_widget_message_poll">
+			<div class="tgme_widget_message_poll_question">Which one?</div>
+			<div class="tgme_widget_message_poll_type">Anonymous poll</div>
+			<div class="tgme_widget_message_poll_options">
+				<div class="tgme_widget_message_poll_option">
+					<div class="tgme_widget_message_poll_option_percent">67%</div>
+					<div class="tgme_widget_message_poll_option_value"><div class="tgme_widget_message_poll_option_text">First</div></div>
+				</div>
+				<div class="tgme_widget_message_poll_option">
+					<div class="tgme_widget_message_poll_option_percent">33%</div>
+					<div class="tgme_widget_message_poll_option_value"><div class="tgme_widget_message_poll_option_text">Second</div></div>
+				</div>
+			</div>
+		</div>
+		<div class="tgme_widget_message_footer"><span class="tgme_widget_message_voters">1.5K voters</span></div>
+	</div>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	poll := extractPoll(&colly.HTMLElement{DOM: doc.Selection})
+
+	require.NotNil(t, poll)
+	assert.Equal(t, "Which one?", poll.Question)
+	assert.Equal(t, "Anonymous poll", poll.Type)
+	assert.Equal(t, 1500, poll.Voters)
+	require.Len(t, poll.Options, 2)
+	assert.Equal(t, "First", poll.Options[0].Text)
+	assert.Equal(t, 67, poll.Options[0].Percent)
+	assert.Equal(t, "Second", poll.Options[1].Text)
+	assert.Equal(t, 33, poll.Options[1].Percent)
+}
+
+func TestExtractForwardAndReply(t *testing.T) {
+	tests := []struct {
+		name            string
+		html            string
+		expectedForward *entity.Forward
+		expectedReply   *entity.Reply
+	}{
+		{
+			name:            "Forwarded from a channel post",
+			html:            `<div class="tgme_widget_message_forwarded_from">Forwarded from <a class="tgme_widget_message_forwarded_from_name" href="https://t.me/durov/123"><span dir="auto">Pavel Durov</span></a></div>`,
+			expectedForward: &entity.Forward{Name: "Pavel Durov", ChannelURL: "https://t.me/durov", PostURL: "https://t.me/durov/123"},
+		},
+		{
+			name:            "Forwarded from a hidden user",
+			html:            `<div class="tgme_widget_message_forwarded_from">Forwarded from <span class="tgme_widget_message_forwarded_from_name">John</span></div>`,
+			expectedForward: &entity.Forward{Name: "John"},
+		},
+		{
+			name:          "Reply",
+			html:          `<a class="tgme_widget_message_reply" href="https://t.me/testch/10"><div class="tgme_widget_message_author"><span class="tgme_widget_message_author_name">Test channel</span></div><div class="tgme_widget_message_metatext">Original text</div></a>`,
+			expectedReply: &entity.Reply{Author: "Test channel", Text: "Original text", URL: "https://t.me/testch/10"},
+		},
+		{
+			name: "Neither",
+			html: `<div class="tgme_widget_message_text">Just text</div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			require.NoError(t, err)
+
+			element := &colly.HTMLElement{DOM: doc.Selection}
+
+			assert.Equal(t, tt.expectedForward, extractForward(element))
+			assert.Equal(t, tt.expectedReply, extractReply(element))
+		})
+	}
+}
+
+func TestExtractEngagement(t *testing.T) {
+	html := `<div class="tgme_widget_message">
+		<div class="tgme_widget_message_reactions">
+			<span class="tgme_reaction"><i class="emoji"><b>👍</b></i>1.2K</span>
+			<span class="tgme_reaction"><tg-emoji emoji-id="1"><i class="emoji"></i></tg-emoji>56</span>
+		</div>
+		<div class="tgme_widget_message_footer">
+			<span class="tgme_widget_message_views">15.3K</span>
+			<span class="tgme_widget_message_meta">edited <a class="tgme_widget_message_date"><time datetime="2025-04-30T07:27:00+00:00">07:27</time></a></span>
+		</div>
+	</div>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	element := &colly.HTMLElement{DOM: doc.Selection}
+
+	assert.Equal(t, []entity.Reaction{
+		{Emoji: "👍", Count: 1200},
+		{Emoji: "", Count: 56},
+	}, extractReactions(element))
+	assert.True(t, isEdited(element))
+}
+
+func TestIsEdited(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     string
+		expected bool
+	}{
+		{
+			name:     "Edited",
+			meta:     `<span class="tgme_widget_message_from_author">Editorial Team</span>edited <a class="tgme_widget_message_date"><time datetime="2025-04-30T07:27:00+00:00">07:27</time></a>`,
+			expected: true,
+		},
+		{
+			name:     "Edited inside the date link",
+			meta:     `<a class="tgme_widget_message_date">edited <time datetime="2025-04-30T07:27:00+00:00">07:27</time></a>`,
+			expected: true,
+		},
+		{
+			name:     "Author signature with the word",
+			meta:     `<span class="tgme_widget_message_from_author">Edited Team</span><a class="tgme_widget_message_date"><time datetime="2025-04-30T07:27:00+00:00">07:27</time></a>`,
+			expected: false,
+		},
+		{
+			name:     "Author signature containing the word",
+			meta:     `<span class="tgme_widget_message_from_author">Unedited</span><a class="tgme_widget_message_date"><time datetime="2025-04-30T07:27:00+00:00">07:27</time></a>`,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := `<div class="tgme_widget_message"><span class="tgme_widget_message_meta">` + tt.meta + `</span></div>`
+
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, isEdited(&colly.HTMLElement{DOM: doc.Selection}))
+		})
+	}
+}
+
+func TestFillMediaSizes(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/video.mp4", "/voice.ogg":
+			w.Header().Set("Content-Length", "1024")
+		default:
+			_, _ = w.Write([]byte("image"))
+		}
+	}))
+	defer srv.Close()
+
+	posts := []entity.Post{
+		{
+			Images: []entity.Image{{URL: srv.URL + "/1.jpg"}, {URL: srv.URL + "/2.jpg"}},
+			Videos: []entity.Video{{URL: srv.URL + "/video.mp4"}},
+		},
+		{
+			Preview: &entity.Image{URL: srv.URL + "/1.jpg"},
+			Audios:  []entity.Audio{{URL: srv.URL + "/voice.ogg"}, {Title: "Not playable"}},
+		},
+	}
+	posts[0].Preview = &posts[0].Images[0]
+
+	fillMediaSizes(posts)
+
+	assert.Equal(t, int64(5), posts[0].Images[0].Size)
+	assert.Equal(t, int64(5), posts[0].Images[1].Size)
+	assert.Equal(t, int64(5), posts[0].Preview.Size)
+	assert.Equal(t, int64(1024), posts[0].Videos[0].Size)
+	assert.Equal(t, int64(5), posts[1].Preview.Size)
+	assert.Equal(t, int64(1024), posts[1].Audios[0].Size)
+	assert.Zero(t, posts[1].Audios[1].Size)
+
+	// Every file is looked up once
+	assert.Equal(t, map[string]int{
+		"GET /1.jpg":      1,
+		"GET /2.jpg":      1,
+		"HEAD /video.mp4": 1,
+		"HEAD /voice.ogg": 1,
+	}, requests)
+}
+
+func TestExtractLinkPreview(t *testing.T) {
+	html := `<a class="tgme_widget_message_link_preview" href="https://example.com/article">
+		<i class="link_preview_right_image" style="background-image:url('https://cdn.example.com/preview.jpg')"></i>
+		<div class="link_preview_site_name">Example</div>
+		<div class="link_preview_title">Article title</div>
+		<div class="link_preview_description">Article description</div>
+	</a>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	linkPreview := extractLinkPreview(&colly.HTMLElement{DOM: doc.Selection})
+
+	assert.Equal(t, &entity.LinkPreview{
+		URL:         "https://example.com/article",
+		SiteName:    "Example",
+		Title:       "Article title",
+		Description: "Article description",
+		ImageURL:    "https://cdn.example.com/preview.jpg",
+	}, linkPreview)
+
+	preview := extractPreview(linkPreview)
+
+	require.NotNil(t, preview, "Preview image should be used whatever the link is")
+	assert.Equal(t, "https://cdn.example.com/preview.jpg", preview.URL)
+	assert.Equal(t, "image/jpeg", preview.Type)
+
+	assert.Nil(t, extractPreview(&entity.LinkPreview{URL: "https://example.com/article"}))
+
+	// Preview images at Telegram CDN usually have no extension
+	preview = extractPreview(&entity.LinkPreview{ImageURL: "https://cdn4.telesco.pe/file/abc"})
+
+	require.NotNil(t, preview)
+	assert.Equal(t, "image/jpeg", preview.Type)
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{text: "950", expected: 950},
+		{text: "1.2K", expected: 1200},
+		{text: "15K", expected: 15000},
+		{text: "3M", expected: 3000000},
+		{text: "2,5M", expected: 2500000},
+		{text: "", expected: 0},
+		{text: "many", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseCount(tt.text))
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{text: "0:38", expected: 38},
+		{text: "12:01", expected: 721},
+		{text: "1:02:03", expected: 3723},
+		{text: "", expected: 0},
+		{text: "live", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseDuration(tt.text))
+		})
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	html := `<div class="tgme_widget_message">
+		<a class="tgme_widget_message_reply" href="https://t.me/testch/1">
+			<div class="tgme_widget_message_metatext">Quote with <a href="?q=%23quoted">#quoted</a></div>
+		</a>
+		<div class="tgme_widget_message_text js-message_text">
+			Release notes <a href="?q=%23news">#news</a> <a href="?q=%23Go">#Go</a> <a href="?q=%23go">#go</a>
+			<a href="https://example.com">link</a>
+		</div>
+	</div>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+
+	tags := extractTags(&colly.HTMLElement{DOM: doc.Selection})
+
+	assert.Contains(t, tags, "news")
+	assert.Contains(t, tags, "Go")
+	assert.NotContains(t, tags, "go", "Hashtags should be deduplicated case-insensitively")
+	assert.NotContains(t, tags, "quoted", "Hashtags of the replied message should be skipped")
+}
@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/nDmitry/tgfeed/internal/entity"
+)
+
+// filterTarget builds the plain-text view of the post for filters
+func filterTarget(p entity.Post) *entity.FilterTarget {
+	target := &entity.FilterTarget{
+		Title:      p.Title,
+		Hashtags:   p.Tags,
+		Views:      p.Views,
+		HasPhoto:   len(p.Images) > 0,
+		HasVideo:   len(p.Videos) > 0,
+		HasAudio:   len(p.Audios) > 0,
+		HasFile:    len(p.Attachments) > 0,
+		HasPoll:    p.Poll != nil,
+		HasForward: p.ForwardedFrom != nil,
+	}
+
+	texts := []string{plainText(p.ContentHTML)}
+
+	if doc, err := goquery.NewDocumentFromReader(strings.NewReader(p.ContentHTML)); err == nil {
+		doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+			if href := s.AttrOr("href", ""); strings.HasPrefix(href, "http") {
+				target.Links = append(target.Links, href)
+			}
+		})
+	}
+
+	if p.LinkPreview != nil {
+		texts = append(texts, p.LinkPreview.SiteName, p.LinkPreview.Title, p.LinkPreview.Description)
+
+		if p.LinkPreview.URL != "" {
+			target.Links = append(target.Links, p.LinkPreview.URL)
+		}
+	}
+
+	if p.Poll != nil {
+		texts = append(texts, p.Poll.Question)
+
+		for _, o := range p.Poll.Options {
+			texts = append(texts, o.Text)
+		}
+	}
+
+	target.Text = strings.Join(texts, "\n")
+	target.HasLink = len(target.Links) > 0
+
+	return target
+}
+
+// plainText strips the markup off the post content keeping line breaks
+func plainText(contentHTML string) string {
+	if contentHTML == "" {
+		return ""
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(breaksRegex.ReplaceAllString(contentHTML, "\n")))
+
+	if err != nil {
+		return contentHTML
+	}
+
+	return strings.TrimSpace(doc.Text())
+}
@@ -2,6 +2,8 @@ package feed
 
 import (
 	"fmt"
+	"html"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -13,37 +15,43 @@ type Generator struct{}
 
 // Generate creates a feed from a channel and returns it as a byte array
 func (g *Generator) Generate(channel *entity.Channel, params *entity.FeedParams) ([]byte, error) {
+	title := channel.Title
+
+	if params.Query != "" {
+		title = fmt.Sprintf("%s: %s", channel.Title, params.Query)
+	}
+
 	feed := &feeds.Feed{
-		Title: channel.Title,
-		Link:  &feeds.Link{Href: channel.URL},
-		Image: &feeds.Image{Url: channel.ImageURL, Title: channel.Title, Link: channel.URL},
-		Items: make([]*feeds.Item, 0, len(channel.Posts)),
+		Title:       title,
+		Description: channel.Description,
+		Link:        &feeds.Link{Href: channel.URL},
+		Image:       &feeds.Image{Url: channel.ImageURL, Title: channel.Title, Link: channel.URL},
+		Items:       make([]*feeds.Item, 0, len(channel.Posts)),
 	}
 
+	// Posts that made it into the feed, in the order of feed items
+	posts := make([]entity.Post, 0, len(channel.Posts))
+
 	for _, p := range channel.Posts {
-		if g.shouldExcludePost(p.ContentHTML, params.ExcludeWords, params.ExcludeCaseSensitive) {
+		if g.shouldSkipPost(p, params) {
 			continue
 		}
 
 		item := &feeds.Item{
-			Id:      strconv.Itoa(p.ID),
-			Title:   p.Title,
-			Content: p.ContentHTML,
-			Link:    &feeds.Link{Href: p.URL},
-			Created: p.Datetime,
+			Id:        strconv.Itoa(p.ID),
+			Title:     p.Title,
+			Content:   g.renderContent(p, params.ShowStats),
+			Link:      &feeds.Link{Href: p.URL},
+			Created:   p.Datetime,
+			Enclosure: g.enclosure(p, params.Format),
 		}
 
-		if p.Preview != nil {
-			item.Enclosure = &feeds.Enclosure{
-				Url:    p.Preview.URL,
-				Type:   p.Preview.Type,
-				Length: strconv.Itoa(int(p.Preview.Size)),
-			}
+		if p.Author != "" {
+			item.Author = &feeds.Author{Name: p.Author}
 		}
 
-		item.Content = g.appendGallery(item.Content, p.Images)
-
 		feed.Add(item)
+		posts = append(posts, p)
 
 		if feed.Created.IsZero() || p.Datetime.After(feed.Created) {
 			feed.Created = p.Datetime
@@ -55,9 +63,13 @@ func (g *Generator) Generate(channel *entity.Channel, params *entity.FeedParams)
 
 	switch params.Format {
 	case entity.FormatRSS:
-		content, err = feed.ToRss()
+		content, err = feeds.ToXML(g.rss(feed, posts))
 	case entity.FormatAtom:
-		content, err = feed.ToAtom()
+		content, err = feeds.ToXML(g.atom(feed, posts))
+	case entity.FormatPodcast:
+		content, err = feeds.ToXML(g.podcast(feed, channel, posts))
+	case entity.FormatJSON:
+		content, err = g.jsonFeed(feed, channel, posts).ToJSON()
 	default:
 		return nil, fmt.Errorf("unsupported feed format: %s", params.Format)
 	}
@@ -69,6 +81,321 @@ func (g *Generator) Generate(channel *entity.Channel, params *entity.FeedParams)
 	return []byte(content), nil
 }
 
+// renderContent renders the post text along with its context and attachments as HTML
+func (g *Generator) renderContent(p entity.Post, showStats bool) string {
+	content := p.ContentHTML
+	content = g.prependReply(content, p.ReplyTo)
+	content = g.prependForward(content, p.ForwardedFrom)
+	content = g.appendLinkPreview(content, p.LinkPreview)
+	content = g.appendVideos(content, p.Videos)
+	content = g.appendAudios(content, p.Audios)
+	content = g.appendAttachments(content, p.URL, p.Attachments)
+	content = g.appendPoll(content, p.Poll)
+	content = g.appendGallery(content, p.Images)
+
+	if showStats {
+		content = g.appendStats(content, p)
+	}
+
+	return content
+}
+
+// rss converts the feed to RSS with author signatures as dc:creator, hashtags as categories
+// and all images and videos as Media RSS. Posts must be in the order of feed items.
+func (g *Generator) rss(feed *feeds.Feed, posts []entity.Post) *rssFeedXML {
+	rss := newRSSFeedXML(feed)
+
+	for i, item := range rss.Channel.Items {
+		if posts[i].Author != "" {
+			item.DCCreator = posts[i].Author
+			rss.DCNamespace = dcNamespace
+		}
+
+		if group := mediaGroupOf(posts[i]); group != nil {
+			item.MediaGroup = group
+			rss.MediaNamespace = mediaNamespace
+		}
+
+		item.Categories = posts[i].Tags
+	}
+
+	return rss
+}
+
+// mediaGroupOf lists images and videos of the post, nil if it has none
+func mediaGroupOf(p entity.Post) *mediaGroup {
+	if len(p.Images) == 0 && len(p.Videos) == 0 {
+		return nil
+	}
+
+	group := &mediaGroup{Contents: make([]*mediaContent, 0, len(p.Images)+len(p.Videos))}
+
+	for _, img := range p.Images {
+		group.Contents = append(group.Contents, &mediaContent{
+			URL:      img.URL,
+			Type:     img.Type,
+			Medium:   "image",
+			FileSize: img.Size,
+			Width:    img.Width,
+			Height:   img.Height,
+		})
+	}
+
+	for _, v := range p.Videos {
+		content := &mediaContent{
+			URL:      v.URL,
+			Type:     v.Type,
+			Medium:   "video",
+			FileSize: v.Size,
+			Duration: v.Duration,
+			Width:    v.Width,
+			Height:   v.Height,
+		}
+
+		if v.ThumbnailURL != "" {
+			content.Thumbnail = &mediaThumbnail{URL: v.ThumbnailURL}
+		}
+
+		group.Contents = append(group.Contents, content)
+	}
+
+	return group
+}
+
+// enclosure picks the post attachment for the feed item enclosure:
+// a photo or an image preview, then a video, then an audio.
+// Podcasts always get an audio.
+func (g *Generator) enclosure(p entity.Post, format string) *feeds.Enclosure {
+	audio := playableAudio(p.Audios)
+
+	switch {
+	case format != entity.FormatPodcast && p.Preview != nil:
+		return &feeds.Enclosure{
+			Url:    p.Preview.URL,
+			Type:   p.Preview.Type,
+			Length: strconv.Itoa(int(p.Preview.Size)),
+		}
+	case format != entity.FormatPodcast && len(p.Videos) > 0:
+		return &feeds.Enclosure{
+			Url:    p.Videos[0].URL,
+			Type:   p.Videos[0].Type,
+			Length: strconv.FormatInt(p.Videos[0].Size, 10),
+		}
+	case audio != nil:
+		return &feeds.Enclosure{
+			Url:    audio.URL,
+			Type:   audio.Type,
+			Length: strconv.FormatInt(audio.Size, 10),
+		}
+	}
+
+	return nil
+}
+
+// playableAudio returns the first audio that has a file to play
+func playableAudio(audios []entity.Audio) *entity.Audio {
+	for i := range audios {
+		if audios[i].URL != "" {
+			return &audios[i]
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) prependForward(content string, forward *entity.Forward) string {
+	if forward == nil {
+		return content
+	}
+
+	source := html.EscapeString(forward.Name)
+
+	if forward.ChannelURL != "" {
+		source = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(forward.ChannelURL), source)
+	}
+
+	header := fmt.Sprintf(`<p class="forwarded-from"><i>Forwarded from %s`, source)
+
+	if forward.PostURL != "" {
+		header += fmt.Sprintf(` (<a href="%s">original post</a>)`, html.EscapeString(forward.PostURL))
+	}
+
+	header += "</i></p>"
+
+	return header + content
+}
+
+func (g *Generator) prependReply(content string, reply *entity.Reply) string {
+	if reply == nil {
+		return content
+	}
+
+	author := html.EscapeString(reply.Author)
+
+	if reply.URL != "" {
+		author = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(reply.URL), author)
+	}
+
+	return fmt.Sprintf(
+		`<blockquote class="reply">In reply to %s: %s</blockquote>`,
+		author, html.EscapeString(reply.Text),
+	) + content
+}
+
+func (g *Generator) appendLinkPreview(content string, preview *entity.LinkPreview) string {
+	if preview == nil || preview.URL == "" {
+		return content
+	}
+
+	if content != "" {
+		content += "<br><br>"
+	}
+
+	content += `<blockquote class="link-preview">`
+
+	if preview.SiteName != "" {
+		content += fmt.Sprintf(`<p><b>%s</b></p>`, html.EscapeString(preview.SiteName))
+	}
+
+	title := preview.Title
+
+	if title == "" {
+		title = preview.URL
+	}
+
+	content += fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(preview.URL), html.EscapeString(title))
+
+	if preview.Description != "" {
+		content += fmt.Sprintf(`<p>%s</p>`, html.EscapeString(preview.Description))
+	}
+
+	if preview.ImageURL != "" {
+		content += fmt.Sprintf(`<p><img src="%s" alt="Preview" /></p>`, html.EscapeString(preview.ImageURL))
+	}
+
+	content += "</blockquote>"
+
+	return content
+}
+
+func (g *Generator) appendVideos(content string, videos []entity.Video) string {
+	if len(videos) == 0 {
+		return content
+	}
+
+	if content != "" {
+		content += "<br><br>"
+	}
+
+	for _, v := range videos {
+		content += fmt.Sprintf(
+			`<p><video src="%s" poster="%s" controls preload="none"><a href="%s">Video</a></video></p>`,
+			html.EscapeString(v.URL), html.EscapeString(v.ThumbnailURL), html.EscapeString(v.URL),
+		)
+	}
+
+	return content
+}
+
+func (g *Generator) appendAudios(content string, audios []entity.Audio) string {
+	if len(audios) == 0 {
+		return content
+	}
+
+	if content != "" {
+		content += "<br><br>"
+	}
+
+	for _, a := range audios {
+		title := a.Title
+
+		if a.Performer != "" {
+			title = a.Performer + " — " + title
+		}
+
+		title = html.EscapeString(title)
+
+		if a.URL == "" {
+			content += fmt.Sprintf(`<p>🎵 %s</p>`, title)
+			continue
+		}
+
+		content += fmt.Sprintf(
+			`<p>🎵 %s</p><p><audio src="%s" controls preload="none"><a href="%s">%s</a></audio></p>`,
+			title, html.EscapeString(a.URL), html.EscapeString(a.URL), title,
+		)
+	}
+
+	return content
+}
+
+func (g *Generator) appendAttachments(content string, postURL string, attachments []entity.Attachment) string {
+	if len(attachments) == 0 {
+		return content
+	}
+
+	if content != "" {
+		content += "<br><br>"
+	}
+
+	content += `<ul class="attachments">`
+
+	for _, a := range attachments {
+		content += fmt.Sprintf(`<li>📎 <a href="%s">%s</a>`, html.EscapeString(postURL), html.EscapeString(a.Name))
+
+		if a.SizeText != "" {
+			content += fmt.Sprintf(" (%s)", html.EscapeString(a.SizeText))
+		}
+
+		content += "</li>"
+	}
+
+	content += "</ul>"
+
+	return content
+}
+
+// appendPoll renders poll results as a table with text bars,
+// since feed readers usually strip inline styles
+func (g *Generator) appendPoll(content string, poll *entity.Poll) string {
+	if poll == nil {
+		return content
+	}
+
+	if content != "" {
+		content += "<br><br>"
+	}
+
+	content += fmt.Sprintf(`<table class="poll"><caption><b>📊 %s</b></caption>`, html.EscapeString(poll.Question))
+
+	for _, o := range poll.Options {
+		bar := strings.Repeat("█", max(0, min(o.Percent, 100))/5)
+
+		content += fmt.Sprintf(
+			`<tr><td>%s</td><td align="right">%d%%</td><td>%s</td></tr>`,
+			html.EscapeString(o.Text), o.Percent, bar,
+		)
+	}
+
+	content += "</table>"
+
+	footer := html.EscapeString(poll.Type)
+
+	if poll.Voters > 0 {
+		if footer != "" {
+			footer += " · "
+		}
+
+		footer += fmt.Sprintf("%d voters", poll.Voters)
+	}
+
+	if footer != "" {
+		content += fmt.Sprintf("<p><i>%s</i></p>", footer)
+	}
+
+	return content
+}
+
 func (g *Generator) appendGallery(content string, images []entity.Image) string {
 	if len(images) == 0 {
 		return content
@@ -81,7 +408,7 @@ func (g *Generator) appendGallery(content string, images []entity.Image) string
 	content += `<div class="image-gallery">`
 
 	for _, img := range images {
-		content += fmt.Sprintf(`<p><img src="%s" alt="Image" /></p>`, img.URL)
+		content += fmt.Sprintf(`<p><img src="%s" alt="Image" /></p>`, html.EscapeString(img.URL))
 	}
 
 	content += "</div>"
@@ -89,17 +416,126 @@ func (g *Generator) appendGallery(content string, images []entity.Image) string
 	return content
 }
 
+// appendStats adds a footer with views, reactions and the edited mark
+func (g *Generator) appendStats(content string, p entity.Post) string {
+	var stats []string
+
+	if p.Views > 0 {
+		stats = append(stats, fmt.Sprintf("👁 %d", p.Views))
+	}
+
+	for _, r := range p.Reactions {
+		emoji := r.Emoji
+
+		if emoji == "" {
+			emoji = "✨" // custom emoji are images at t.me
+		}
+
+		stats = append(stats, fmt.Sprintf("%s %d", html.EscapeString(emoji), r.Count))
+	}
+
+	if p.Edited {
+		stats = append(stats, "edited")
+	}
+
+	if len(stats) == 0 {
+		return content
+	}
+
+	if content != "" {
+		content += "<br><br>"
+	}
+
+	return content + fmt.Sprintf(`<p class="stats"><i>%s</i></p>`, strings.Join(stats, " · "))
+}
+
+// shouldSkipPost checks if a post should be left out of the feed
+func (g *Generator) shouldSkipPost(p entity.Post, params *entity.FeedParams) bool {
+	if g.shouldExcludePost(p.ContentHTML, params.ExcludeWords, params.ExcludeCaseSensitive) {
+		return true
+	}
+
+	if !g.shouldIncludePost(p.ContentHTML, params.IncludeWords, params.IncludeCaseSensitive) {
+		return true
+	}
+
+	if len(params.ExcludePatterns) > 0 || len(params.IncludePatterns) > 0 {
+		text := filterTarget(p).Text
+
+		if entity.MatchAnyPattern(text, params.ExcludePatterns) {
+			return true
+		}
+
+		if len(params.IncludePatterns) > 0 && !entity.MatchAnyPattern(text, params.IncludePatterns) {
+			return true
+		}
+	}
+
+	// Every podcast episode needs an audio enclosure
+	if params.Format == entity.FormatPodcast && playableAudio(p.Audios) == nil {
+		return true
+	}
+
+	if params.AttachmentsOnly && len(p.Attachments) == 0 {
+		return true
+	}
+
+	if params.ExcludeForwards && p.ForwardedFrom != nil {
+		return true
+	}
+
+	if params.ExcludeAds && p.Sponsored {
+		return true
+	}
+
+	if params.MinViews > 0 && p.Views < params.MinViews {
+		return true
+	}
+
+	if len(params.Authors) > 0 && !slices.ContainsFunc(params.Authors, func(author string) bool {
+		return strings.EqualFold(author, p.Author)
+	}) {
+		return true
+	}
+
+	if len(params.Tags) > 0 && !slices.ContainsFunc(params.Tags, func(tag string) bool {
+		return slices.ContainsFunc(p.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}) {
+		return true
+	}
+
+	if params.Filter != nil && !params.Filter.Match(filterTarget(p)) {
+		return true
+	}
+
+	return false
+}
+
 // shouldExcludePost checks if a post should be excluded based on exclude words
 func (g *Generator) shouldExcludePost(content string, excludeWords []string, caseSensitive bool) bool {
 	if len(excludeWords) == 0 {
 		return false
 	}
 
+	return containsAnyWord(content, excludeWords, caseSensitive)
+}
+
+// shouldIncludePost checks if a post matches at least one of include words, if any
+func (g *Generator) shouldIncludePost(content string, includeWords []string, caseSensitive bool) bool {
+	if len(includeWords) == 0 {
+		return true
+	}
+
+	return containsAnyWord(content, includeWords, caseSensitive)
+}
+
+// containsAnyWord checks if the content contains at least one of the words
+func containsAnyWord(content string, words []string, caseSensitive bool) bool {
 	if !caseSensitive {
 		content = strings.ToLower(content)
 	}
 
-	for _, word := range excludeWords {
+	for _, word := range words {
 		if !caseSensitive {
 			word = strings.ToLower(word)
 		}
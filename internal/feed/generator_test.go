@@ -0,0 +1,704 @@
+package feed_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nDmitry/tgfeed/internal/entity"
+	"github.com/nDmitry/tgfeed/internal/feed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestChannel(posts ...entity.Post) *entity.Channel {
+	return &entity.Channel{
+		Username:    "testch",
+		Title:       "Test channel",
+		URL:         "https://t.me/s/testch",
+		ImageURL:    "https://example.com/avatar.jpg",
+		Description: "Channel for tests",
+		Posts:       posts,
+	}
+}
+
+func mustParseFilter(t *testing.T, source string) *entity.Filter {
+	t.Helper()
+
+	filter, err := entity.ParseFilter(source)
+	require.NoError(t, err)
+
+	return filter
+}
+
+func mustParsePattern(t *testing.T, source string) *entity.Pattern {
+	t.Helper()
+
+	pattern, err := entity.ParsePattern(source)
+	require.NoError(t, err)
+
+	return pattern
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	dt := time.Date(2025, 4, 30, 7, 27, 0, 0, time.UTC)
+
+	textPost := entity.Post{
+		ID:          1,
+		URL:         "https://t.me/testch/1",
+		Title:       "Text post",
+		ContentHTML: "Just text",
+		Datetime:    dt,
+	}
+
+	videoPost := entity.Post{
+		ID:          2,
+		URL:         "https://t.me/testch/2",
+		Title:       "Video post",
+		ContentHTML: "Watch this",
+		Videos: []entity.Video{{
+			URL:          "https://example.com/video.mp4",
+			Type:         "video/mp4",
+			ThumbnailURL: "https://example.com/thumb.jpg",
+			Duration:     38,
+			Size:         2048,
+		}},
+		Datetime: dt.Add(time.Hour),
+	}
+
+	voicePost := entity.Post{
+		ID:    3,
+		URL:   "https://t.me/testch/3",
+		Title: "Voice post",
+		Audios: []entity.Audio{{
+			URL:      "https://example.com/voice.ogg",
+			Type:     "audio/ogg",
+			Title:    "Voice message",
+			Duration: 125,
+			Size:     4096,
+		}},
+		Datetime: dt.Add(2 * time.Hour),
+	}
+
+	documentPost := entity.Post{
+		ID:          4,
+		URL:         "https://t.me/testch/4",
+		Title:       "Document post",
+		ContentHTML: "Monthly report",
+		Attachments: []entity.Attachment{{
+			Name:      "report.pdf",
+			SizeText:  "1.2 MB",
+			Extension: "pdf",
+		}},
+		Datetime: dt.Add(3 * time.Hour),
+	}
+
+	pollPost := entity.Post{
+		ID:    5,
+		URL:   "https://t.me/testch/5",
+		Title: "Which one?",
+		Poll: &entity.Poll{
+			Question: "Which one?",
+			Type:     "Anonymous poll",
+			Options: []entity.PollOption{
+				{Text: "First", Percent: 60},
+				{Text: "Second", Percent: 40},
+			},
+			Voters: 1500,
+		},
+		Datetime: dt.Add(4 * time.Hour),
+	}
+
+	forwardedPost := entity.Post{
+		ID:          6,
+		URL:         "https://t.me/testch/6",
+		Title:       "Forwarded post",
+		ContentHTML: "Repost",
+		ForwardedFrom: &entity.Forward{
+			Name:       "Other channel",
+			ChannelURL: "https://t.me/other",
+			PostURL:    "https://t.me/other/1",
+		},
+		Datetime: dt.Add(5 * time.Hour),
+	}
+
+	popularPost := entity.Post{
+		ID:          7,
+		URL:         "https://t.me/testch/7",
+		Title:       "Popular post",
+		ContentHTML: "Hot take",
+		Views:       15300,
+		Reactions:   []entity.Reaction{{Emoji: "👍", Count: 1200}},
+		Edited:      true,
+		Datetime:    dt.Add(6 * time.Hour),
+	}
+
+	signedPost := entity.Post{
+		ID:          8,
+		URL:         "https://t.me/testch/8",
+		Title:       "Signed post",
+		ContentHTML: "Column",
+		Author:      "Jane Doe",
+		Datetime:    dt.Add(7 * time.Hour),
+	}
+
+	linkPost := entity.Post{
+		ID:          9,
+		URL:         "https://t.me/testch/9",
+		Title:       "Link post",
+		ContentHTML: "Read this",
+		LinkPreview: &entity.LinkPreview{
+			URL:         "https://example.com/article",
+			SiteName:    "Example",
+			Title:       "Article & more",
+			Description: "Article description",
+			ImageURL:    "https://example.com/preview.jpg",
+		},
+		Datetime: dt.Add(8 * time.Hour),
+	}
+
+	// URLs taken from t.me markup may break out of attributes
+	injectionPost := entity.Post{
+		ID:          14,
+		URL:         "https://t.me/testch/14",
+		Title:       "Injection post",
+		ContentHTML: "Look",
+		ForwardedFrom: &entity.Forward{
+			Name:       "Other <channel>",
+			ChannelURL: `https://t.me/other" onclick="alert(1)`,
+		},
+		LinkPreview: &entity.LinkPreview{
+			URL:      `https://example.com/?a=1&b="><script>alert(1)</script>`,
+			Title:    "Article",
+			ImageURL: `https://example.com/preview.jpg" onerror="alert(1)`,
+		},
+		Images:   []entity.Image{{URL: `https://example.com/1.jpg"><script>`, Type: "image/jpeg"}},
+		Videos:   []entity.Video{{URL: `https://example.com/v.mp4" autoplay="`, Type: "video/mp4"}},
+		Datetime: dt.Add(12 * time.Hour),
+	}
+
+	markupPost := entity.Post{
+		ID:          11,
+		URL:         "https://t.me/testch/11",
+		Title:       "Markup post",
+		ContentHTML: `Read <a href="https://crypto.example/news">here</a><br/><a href="?q=%23news">#news</a>`,
+		Tags:        []string{"news"},
+		Datetime:    dt.Add(10 * time.Hour),
+	}
+
+	taggedPost := entity.Post{
+		ID:          13,
+		URL:         "https://t.me/testch/13",
+		Title:       "Tagged post",
+		ContentHTML: `New version <a href="?q=%23news">#news</a> <a href="?q=%23Go">#Go</a>`,
+		Tags:        []string{"news", "Go"},
+		Datetime:    dt.Add(11 * time.Hour),
+	}
+
+	galleryPost := entity.Post{
+		ID:          10,
+		URL:         "https://t.me/testch/10",
+		Title:       "Gallery post",
+		ContentHTML: "Photos",
+		Preview:     &entity.Image{URL: "https://example.com/1.jpg", Type: "image/jpeg", Size: 1024},
+		Images: []entity.Image{
+			{URL: "https://example.com/1.jpg", Type: "image/jpeg", Size: 1024, Width: 800, Height: 600},
+			{URL: "https://example.com/2.png", Type: "image/png", Size: 2048, Width: 600, Height: 800},
+		},
+		Datetime: dt.Add(9 * time.Hour),
+	}
+
+	tests := []struct {
+		name        string
+		channel     *entity.Channel
+		params      *entity.FeedParams
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:    "Video is rendered as a player and used as an enclosure",
+			channel: newTestChannel(videoPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`<video src="https://example.com/video.mp4" poster="https://example.com/thumb.jpg"`,
+				`<enclosure url="https://example.com/video.mp4" length="2048" type="video/mp4"></enclosure>`,
+			},
+		},
+		{
+			name:    "Podcast contains only posts with audio",
+			channel: newTestChannel(textPost, videoPost, voicePost),
+			params:  &entity.FeedParams{Format: entity.FormatPodcast},
+			contains: []string{
+				`xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+				`<itunes:author>Test channel</itunes:author>`,
+				`<itunes:image href="https://example.com/avatar.jpg"></itunes:image>`,
+				`<enclosure url="https://example.com/voice.ogg" length="4096" type="audio/ogg"></enclosure>`,
+				`<itunes:duration>125</itunes:duration>`,
+			},
+			notContains: []string{
+				"Text post",
+				"Video post",
+			},
+		},
+		{
+			name:    "Regular RSS has no iTunes namespace",
+			channel: newTestChannel(voicePost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`<enclosure url="https://example.com/voice.ogg" length="4096" type="audio/ogg"></enclosure>`,
+			},
+			notContains: []string{
+				"itunes",
+			},
+		},
+		{
+			name:    "Images and videos are listed in Media RSS",
+			channel: newTestChannel(galleryPost, videoPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`xmlns:media="http://search.yahoo.com/mrss/"`,
+				`<media:group>`,
+				`<media:content url="https://example.com/1.jpg" type="image/jpeg" medium="image" fileSize="1024" width="800" height="600"></media:content>`,
+				`<media:content url="https://example.com/2.png" type="image/png" medium="image" fileSize="2048" width="600" height="800"></media:content>`,
+				`<media:content url="https://example.com/video.mp4" type="video/mp4" medium="video" fileSize="2048" duration="38">`,
+				`<media:thumbnail url="https://example.com/thumb.jpg"></media:thumbnail>`,
+			},
+		},
+		{
+			name:    "No Media RSS namespace without media",
+			channel: newTestChannel(textPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			notContains: []string{
+				"xmlns:media",
+				"media:group",
+			},
+		},
+		{
+			name:    "Every image is an Atom enclosure",
+			channel: newTestChannel(galleryPost),
+			params:  &entity.FeedParams{Format: entity.FormatAtom},
+			contains: []string{
+				`<link href="https://example.com/1.jpg" rel="enclosure" type="image/jpeg" length="1024"></link>`,
+				`<link href="https://example.com/2.png" rel="enclosure" type="image/png" length="2048"></link>`,
+			},
+		},
+		{
+			name:    "Only posts with included words",
+			channel: newTestChannel(textPost, signedPost, popularPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, IncludeWords: []string{"just", "HOT"}},
+			contains: []string{
+				"Text post",
+				"Popular post",
+			},
+			notContains: []string{
+				"Signed post",
+			},
+		},
+		{
+			name:    "Included words are matched case-sensitively",
+			channel: newTestChannel(textPost, popularPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, IncludeWords: []string{"Just", "HOT"}, IncludeCaseSensitive: true},
+			contains: []string{
+				"Text post",
+			},
+			notContains: []string{
+				"Popular post",
+			},
+		},
+		{
+			name:    "Excluded words win over included ones",
+			channel: newTestChannel(textPost, popularPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, IncludeWords: []string{"just", "hot"}, ExcludeWords: []string{"take"}},
+			contains: []string{
+				"Text post",
+			},
+			notContains: []string{
+				"Popular post",
+			},
+		},
+		{
+			name:    "Filter is matched against plain text",
+			channel: newTestChannel(textPost, markupPost, linkPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, Filter: mustParseFilter(t, `NOT crypto AND NOT "article & more"`)},
+			contains: []string{
+				"Text post",
+				"Markup post",
+			},
+			notContains: []string{
+				"Link post",
+			},
+		},
+		{
+			name:    "Filter by link and hashtag",
+			channel: newTestChannel(textPost, markupPost, linkPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, Filter: mustParseFilter(t, "link:crypto.example OR hashtag:news")},
+			contains: []string{
+				"Markup post",
+			},
+			notContains: []string{
+				"Text post",
+				"Link post",
+			},
+		},
+		{
+			name:    "Patterns are matched against plain text",
+			channel: newTestChannel(textPost, markupPost, linkPost),
+			params: &entity.FeedParams{
+				Format:          entity.FormatRSS,
+				IncludePatterns: []*entity.Pattern{mustParsePattern(t, `^(Just|Read)\b`)},
+				ExcludePatterns: []*entity.Pattern{mustParsePattern(t, `crypto|(?i)\barticle\b`)},
+			},
+			contains: []string{
+				"Text post",
+				"Markup post",
+			},
+			notContains: []string{
+				"Link post",
+			},
+		},
+		{
+			name:    "Ads are excluded",
+			channel: newTestChannel(textPost, entity.Post{ID: 12, Title: "Ad post", ContentHTML: "Buy now", Sponsored: true, Datetime: dt}),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, ExcludeAds: true},
+			contains: []string{
+				"Text post",
+			},
+			notContains: []string{
+				"Ad post",
+			},
+		},
+		{
+			name:    "Hashtags are RSS categories",
+			channel: newTestChannel(taggedPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`<category>news</category>`,
+				`<category>Go</category>`,
+			},
+		},
+		{
+			name:    "Hashtags are Atom categories",
+			channel: newTestChannel(taggedPost),
+			params:  &entity.FeedParams{Format: entity.FormatAtom},
+			contains: []string{
+				`<category term="news"></category>`,
+				`<category term="Go"></category>`,
+			},
+		},
+		{
+			name:    "Hashtags are JSON Feed tags",
+			channel: newTestChannel(taggedPost),
+			params:  &entity.FeedParams{Format: entity.FormatJSON},
+			contains: []string{
+				`"tags": [`,
+				`"news",`,
+			},
+		},
+		{
+			name:    "Only posts with given hashtags",
+			channel: newTestChannel(textPost, taggedPost, markupPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, Tags: []string{"go", "release"}},
+			contains: []string{
+				"Tagged post",
+			},
+			notContains: []string{
+				"Text post",
+				"Markup post",
+			},
+		},
+		{
+			name:    "Search query is in the feed title",
+			channel: newTestChannel(textPost),
+			params:  &entity.FeedParams{Format: entity.FormatAtom, Query: "release notes"},
+			contains: []string{
+				`<title>Test channel: release notes</title>`,
+			},
+		},
+		{
+			name:    "Only posts with attachments",
+			channel: newTestChannel(textPost, documentPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, AttachmentsOnly: true},
+			contains: []string{
+				`<li>📎 <a href="https://t.me/testch/4">report.pdf</a> (1.2 MB)</li>`,
+			},
+			notContains: []string{
+				"Text post",
+			},
+		},
+		{
+			name:    "Poll is rendered as a table",
+			channel: newTestChannel(pollPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`<title>Which one?</title>`,
+				`<caption><b>📊 Which one?</b></caption>`,
+				`<tr><td>First</td><td align="right">60%</td><td>████████████</td></tr>`,
+				`<p><i>Anonymous poll · 1500 voters</i></p>`,
+			},
+		},
+		{
+			name:    "Forward source is rendered as a header",
+			channel: newTestChannel(forwardedPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`<p class="forwarded-from"><i>Forwarded from <a href="https://t.me/other">Other channel</a> (<a href="https://t.me/other/1">original post</a>)</i></p>Repost`,
+			},
+		},
+		{
+			name:    "Forwards are excluded",
+			channel: newTestChannel(textPost, forwardedPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, ExcludeForwards: true},
+			contains: []string{
+				"Text post",
+			},
+			notContains: []string{
+				"Forwarded post",
+			},
+		},
+		{
+			name:    "Posts with few views are excluded and stats are shown",
+			channel: newTestChannel(textPost, popularPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, MinViews: 1000, ShowStats: true},
+			contains: []string{
+				`<p class="stats"><i>👁 15300 · 👍 1200 · edited</i></p>`,
+			},
+			notContains: []string{
+				"Text post",
+			},
+		},
+		{
+			name:    "Author is emitted as dc:creator in RSS",
+			channel: newTestChannel(textPost, signedPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`xmlns:dc="http://purl.org/dc/elements/1.1/"`,
+				`<author>Jane Doe</author>`,
+				`<dc:creator>Jane Doe</dc:creator>`,
+			},
+		},
+		{
+			name:    "Author is emitted in Atom",
+			channel: newTestChannel(signedPost),
+			params:  &entity.FeedParams{Format: entity.FormatAtom},
+			contains: []string{
+				`<author>`,
+				`<name>Jane Doe</name>`,
+			},
+		},
+		{
+			name:    "Only posts of the given author",
+			channel: newTestChannel(textPost, signedPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS, Authors: []string{"jane doe"}},
+			contains: []string{
+				"Signed post",
+			},
+			notContains: []string{
+				"Text post",
+			},
+		},
+		{
+			name:    "Link preview is rendered as a card",
+			channel: newTestChannel(linkPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`Read this<br><br><blockquote class="link-preview"><p><b>Example</b></p>` +
+					`<p><a href="https://example.com/article">Article &amp; more</a></p><p>Article description</p>` +
+					`<p><img src="https://example.com/preview.jpg" alt="Preview" /></p></blockquote>`,
+			},
+		},
+		{
+			name:    "URLs are escaped in attributes",
+			channel: newTestChannel(injectionPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`Forwarded from <a href="https://t.me/other&#34; onclick=&#34;alert(1)">Other &lt;channel&gt;</a>`,
+				`<a href="https://example.com/?a=1&amp;b=&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">Article</a>`,
+				`<img src="https://example.com/preview.jpg&#34; onerror=&#34;alert(1)" alt="Preview" />`,
+				`<img src="https://example.com/1.jpg&#34;&gt;&lt;script&gt;" alt="Image" />`,
+				`<video src="https://example.com/v.mp4&#34; autoplay=&#34;"`,
+			},
+			notContains: []string{
+				`<script>`,
+				`" onclick="`,
+				`" onerror="`,
+			},
+		},
+		{
+			name:    "Channel description is the RSS description",
+			channel: newTestChannel(textPost),
+			params:  &entity.FeedParams{Format: entity.FormatRSS},
+			contains: []string{
+				`<description>Channel for tests</description>`,
+			},
+		},
+		{
+			name:    "Channel description is the Atom subtitle",
+			channel: newTestChannel(textPost),
+			params:  &entity.FeedParams{Format: entity.FormatAtom},
+			contains: []string{
+				`<subtitle>Channel for tests</subtitle>`,
+			},
+		},
+	}
+
+	generator := &feed.Generator{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := generator.Generate(tt.channel, tt.params)
+			require.NoError(t, err)
+
+			for _, s := range tt.contains {
+				assert.Contains(t, string(content), s)
+			}
+
+			for _, s := range tt.notContains {
+				assert.NotContains(t, string(content), s)
+			}
+		})
+	}
+}
+
+func TestGenerator_GenerateJSONFeed(t *testing.T) {
+	dt := time.Date(2025, 4, 30, 7, 27, 0, 0, time.UTC)
+
+	channel := newTestChannel(
+		entity.Post{
+			ID:          1,
+			URL:         "https://t.me/testch/1",
+			Title:       "Photo post",
+			ContentHTML: "Look",
+			Author:      "Jane Doe",
+			Preview:     &entity.Image{URL: "https://example.com/1.jpg", Type: "image/jpeg", Size: 1024},
+			Images: []entity.Image{
+				{URL: "https://example.com/1.jpg", Type: "image/jpeg", Size: 1024},
+				{URL: "https://example.com/2.jpg", Type: "image/jpeg", Size: 2048},
+			},
+			Datetime: dt,
+		},
+		entity.Post{
+			ID:    2,
+			URL:   "https://t.me/testch/2",
+			Title: "Voice post",
+			Audios: []entity.Audio{{
+				URL:      "https://example.com/voice.ogg",
+				Type:     "audio/ogg",
+				Title:    "Voice message",
+				Duration: 125,
+				Size:     4096,
+			}},
+			Datetime: dt.Add(time.Hour),
+		},
+	)
+
+	content, err := (&feed.Generator{}).Generate(channel, &entity.FeedParams{Format: entity.FormatJSON})
+	require.NoError(t, err)
+
+	var jf struct {
+		Version     string `json:"version"`
+		Title       string `json:"title"`
+		HomePageURL string `json:"home_page_url"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+		Authors     []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		Items []struct {
+			ID      string `json:"id"`
+			URL     string `json:"url"`
+			Image   string `json:"image"`
+			Authors []struct {
+				Name string `json:"name"`
+			} `json:"authors"`
+			Attachments []struct {
+				URL      string  `json:"url"`
+				MIMEType string  `json:"mime_type"`
+				Size     int     `json:"size"`
+				Duration float64 `json:"duration_in_seconds"`
+			} `json:"attachments"`
+		} `json:"items"`
+	}
+
+	require.NoError(t, json.Unmarshal(content, &jf))
+
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", jf.Version)
+	assert.Equal(t, "Test channel", jf.Title)
+	assert.Equal(t, "https://t.me/s/testch", jf.HomePageURL)
+	assert.Equal(t, "Channel for tests", jf.Description)
+	assert.Equal(t, "https://example.com/avatar.jpg", jf.Icon)
+	require.Len(t, jf.Authors, 1)
+	assert.Equal(t, "Test channel", jf.Authors[0].Name)
+
+	require.Len(t, jf.Items, 2)
+
+	photo := jf.Items[0]
+	assert.Equal(t, "1", photo.ID)
+	assert.Equal(t, "https://example.com/1.jpg", photo.Image)
+	require.Len(t, photo.Authors, 1)
+	assert.Equal(t, "Jane Doe", photo.Authors[0].Name)
+	require.Len(t, photo.Attachments, 2)
+	assert.Equal(t, "https://example.com/2.jpg", photo.Attachments[1].URL)
+	assert.Equal(t, 2048, photo.Attachments[1].Size)
+
+	voice := jf.Items[1]
+	require.Len(t, voice.Attachments, 1)
+	assert.Equal(t, "audio/ogg", voice.Attachments[0].MIMEType)
+	assert.InDelta(t, 125, voice.Attachments[0].Duration, 0.001)
+
+	// Items are required even when every post is filtered out
+	content, err = (&feed.Generator{}).Generate(newTestChannel(), &entity.FeedParams{Format: entity.FormatJSON})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"items": []`)
+}
+
+func TestGenerator_GeneratePost(t *testing.T) {
+	channel := newTestChannel(entity.Post{
+		ID:          42,
+		URL:         "https://t.me/testch/42",
+		Title:       "Single <post>",
+		ContentHTML: "<b>Post</b> content",
+		Author:      "Jane Doe",
+		Datetime:    time.Date(2025, 4, 30, 7, 27, 0, 0, time.UTC),
+	})
+
+	generator := &feed.Generator{}
+
+	t.Run("HTML", func(t *testing.T) {
+		content, err := generator.GeneratePost(channel, &entity.PostParams{Format: entity.FormatHTML})
+		require.NoError(t, err)
+
+		assert.Contains(t, string(content), "<title>Single &lt;post&gt;</title>")
+		assert.Contains(t, string(content), `<a href="https://t.me/s/testch">Test channel</a> · Jane Doe`)
+		assert.Contains(t, string(content), `<time datetime="2025-04-30T07:27:00Z">30 Apr 2025 07:27</time>`)
+		assert.Contains(t, string(content), "<b>Post</b> content")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		content, err := generator.GeneratePost(channel, &entity.PostParams{Format: entity.FormatJSON})
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"id": 42,
+			"url": "https://t.me/testch/42",
+			"title": "Single <post>",
+			"author": "Jane Doe",
+			"datetime": "2025-04-30T07:27:00Z",
+			"content_html": "<b>Post</b> content",
+			"views": 0,
+			"channel": {
+				"username": "testch",
+				"title": "Test channel",
+				"url": "https://t.me/s/testch",
+				"image_url": "https://example.com/avatar.jpg"
+			}
+		}`, string(content))
+	})
+
+	t.Run("One-item feed", func(t *testing.T) {
+		content, err := generator.GeneratePost(channel, &entity.PostParams{Format: entity.FormatAtom})
+		require.NoError(t, err)
+
+		assert.Contains(t, string(content), "<entry>")
+		assert.Contains(t, string(content), "<id>42</id>")
+	})
+}
@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"github.com/nDmitry/tgfeed/internal/entity"
+)
+
+// jsonFeed embeds the gorilla/feeds JSON Feed, its items are shadowed
+// to always be present, as JSON Feed 1.1 requires
+type jsonFeed struct {
+	*feeds.JSONFeed
+	Items []*feeds.JSONItem `json:"items"`
+}
+
+// jsonFeed converts the feed to JSON Feed 1.1 with the channel avatar as the icon
+// and all post media as attachments. Posts must be in the order of feed items.
+func (g *Generator) jsonFeed(feed *feeds.Feed, channel *entity.Channel, posts []entity.Post) *jsonFeed {
+	jf := &jsonFeed{JSONFeed: (&feeds.JSON{Feed: feed}).JSONFeed()}
+	jf.Items = make([]*feeds.JSONItem, 0, len(jf.JSONFeed.Items))
+
+	if channel.ImageURL != "" {
+		jf.Icon = channel.ImageURL
+		jf.Favicon = channel.ImageURL
+	}
+
+	jf.Authors = []*feeds.JSONAuthor{{
+		Name:   channel.Title,
+		Url:    channel.URL,
+		Avatar: channel.ImageURL,
+	}}
+
+	for i, item := range jf.JSONFeed.Items {
+		post := posts[i]
+
+		if post.Preview != nil {
+			item.Image = post.Preview.URL
+		}
+
+		item.Attachments = jsonAttachments(post)
+		item.Tags = post.Tags
+		jf.Items = append(jf.Items, item)
+	}
+
+	return jf
+}
+
+// jsonAttachments lists images, videos and playable audios of the post
+func jsonAttachments(p entity.Post) []feeds.JSONAttachment {
+	var attachments []feeds.JSONAttachment
+
+	for _, img := range p.Images {
+		attachments = append(attachments, feeds.JSONAttachment{
+			Url:      img.URL,
+			MIMEType: img.Type,
+			Size:     jsonAttachmentSize(img.Size),
+		})
+	}
+
+	for _, v := range p.Videos {
+		attachments = append(attachments, feeds.JSONAttachment{
+			Url:      v.URL,
+			MIMEType: v.Type,
+			Size:     jsonAttachmentSize(v.Size),
+			Duration: time.Duration(v.Duration) * time.Second,
+		})
+	}
+
+	for _, a := range p.Audios {
+		if a.URL == "" {
+			continue
+		}
+
+		title := a.Title
+
+		if a.Performer != "" {
+			title = a.Performer + " — " + title
+		}
+
+		attachments = append(attachments, feeds.JSONAttachment{
+			Url:      a.URL,
+			MIMEType: a.Type,
+			Title:    title,
+			Size:     jsonAttachmentSize(a.Size),
+			Duration: time.Duration(a.Duration) * time.Second,
+		})
+	}
+
+	return attachments
+}
+
+// jsonAttachmentSize fits the size into gorilla/feeds' int32, leaving out sizes that don't fit
+func jsonAttachmentSize(size int64) int32 {
+	if size <= 0 || size > int64(^uint32(0)>>1) {
+		return 0
+	}
+
+	return int32(size)
+}
+
+// ToJSON encodes the feed the same way gorilla/feeds does
+func (f *jsonFeed) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(f, "", "  ")
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
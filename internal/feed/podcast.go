@@ -0,0 +1,36 @@
+package feed
+
+import (
+	"strconv"
+
+	"github.com/gorilla/feeds"
+	"github.com/nDmitry/tgfeed/internal/entity"
+)
+
+// podcast converts the feed to RSS with iTunes podcast metadata.
+// Posts must be in the order of feed items, each one with a playable audio.
+func (g *Generator) podcast(feed *feeds.Feed, channel *entity.Channel, posts []entity.Post) *rssFeedXML {
+	rss := g.rss(feed, posts)
+	rss.ITunesNamespace = itunesNamespace
+
+	rss.Channel.ITunesAuthor = channel.Title
+	rss.Channel.ITunesExplicit = "false"
+
+	if channel.ImageURL != "" {
+		rss.Channel.ITunesImage = &itunesImage{Href: channel.ImageURL}
+	}
+
+	for i, item := range rss.Channel.Items {
+		post := posts[i]
+
+		if audio := playableAudio(post.Audios); audio != nil && audio.Duration > 0 {
+			item.ITunesDuration = strconv.Itoa(audio.Duration)
+		}
+
+		if post.Preview != nil {
+			item.ITunesImage = &itunesImage{Href: post.Preview.URL}
+		}
+	}
+
+	return rss
+}
@@ -0,0 +1,127 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/nDmitry/tgfeed/internal/entity"
+)
+
+var postTemplate = template.Must(template.New("post").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+</head>
+<body>
+<article>
+<header>
+<h1>{{.Title}}</h1>
+<p><a href="{{.ChannelURL}}">{{.ChannelTitle}}</a>{{with .Author}} · {{.}}{{end}} · <a href="{{.URL}}"><time datetime="{{.Datetime.Format "2006-01-02T15:04:05Z07:00"}}">{{.Datetime.Format "2 Jan 2006 15:04"}}</time></a></p>
+</header>
+{{.Content}}
+</article>
+</body>
+</html>
+`))
+
+type postPage struct {
+	Title        string
+	ChannelTitle string
+	ChannelURL   string
+	Author       string
+	URL          string
+	Datetime     time.Time
+	Content      template.HTML
+}
+
+// postJSON is the JSON representation of a single post
+type postJSON struct {
+	ID          int         `json:"id"`
+	URL         string      `json:"url"`
+	Title       string      `json:"title"`
+	Author      string      `json:"author,omitempty"`
+	Datetime    time.Time   `json:"datetime"`
+	ContentHTML string      `json:"content_html"`
+	ImageURL    string      `json:"image_url,omitempty"`
+	Views       int         `json:"views"`
+	Channel     channelJSON `json:"channel"`
+}
+
+type channelJSON struct {
+	Username string `json:"username"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// GeneratePost renders the only post of a channel as a one-item feed,
+// a JSON object or a standalone HTML page
+func (g *Generator) GeneratePost(channel *entity.Channel, params *entity.PostParams) ([]byte, error) {
+	if len(channel.Posts) == 0 {
+		return nil, fmt.Errorf("channel %s has no posts", channel.Username)
+	}
+
+	p := channel.Posts[0]
+
+	switch params.Format {
+	case entity.FormatRSS, entity.FormatAtom:
+		return g.Generate(channel, &entity.FeedParams{Username: params.Username, Format: params.Format})
+	case entity.FormatJSON:
+		content, err := json.Marshal(postJSON{
+			ID:          p.ID,
+			URL:         p.URL,
+			Title:       p.Title,
+			Author:      p.Author,
+			Datetime:    p.Datetime,
+			ContentHTML: g.renderContent(p, false),
+			ImageURL:    previewURL(p),
+			Views:       p.Views,
+			Channel: channelJSON{
+				Username: channel.Username,
+				Title:    channel.Title,
+				URL:      channel.URL,
+				ImageURL: channel.ImageURL,
+			},
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal post %d to JSON: %w", p.ID, err)
+		}
+
+		return content, nil
+	case entity.FormatHTML:
+		var buf bytes.Buffer
+
+		err := postTemplate.Execute(&buf, postPage{
+			Title:        p.Title,
+			ChannelTitle: channel.Title,
+			ChannelURL:   channel.URL,
+			Author:       p.Author,
+			URL:          p.URL,
+			Datetime:     p.Datetime,
+			// nolint: gosec // the content comes from t.me markup, same as in feeds
+			Content: template.HTML(g.renderContent(p, false)),
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("could not render post %d to HTML: %w", p.ID, err)
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported post format: %s", params.Format)
+	}
+}
+
+func previewURL(p entity.Post) string {
+	if p.Preview == nil {
+		return ""
+	}
+
+	return p.Preview.URL
+}
@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"encoding/xml"
+
+	"github.com/gorilla/feeds"
+)
+
+const (
+	contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+	dcNamespace      = "http://purl.org/dc/elements/1.1/"
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	mediaNamespace   = "http://search.yahoo.com/mrss/"
+)
+
+// rssFeedXML is the <rss> root extended with namespaces gorilla/feeds doesn't support
+type rssFeedXML struct {
+	XMLName          xml.Name `xml:"rss"`
+	Version          string   `xml:"version,attr"`
+	ContentNamespace string   `xml:"xmlns:content,attr"`
+	DCNamespace      string   `xml:"xmlns:dc,attr,omitempty"`
+	ITunesNamespace  string   `xml:"xmlns:itunes,attr,omitempty"`
+	MediaNamespace   string   `xml:"xmlns:media,attr,omitempty"`
+	Channel          *rssChannel
+}
+
+// rssChannel embeds the gorilla/feeds channel, its items are shadowed by the extended ones
+type rssChannel struct {
+	XMLName xml.Name `xml:"channel"`
+	*feeds.RssFeed
+	ITunesAuthor   string `xml:"itunes:author,omitempty"`
+	ITunesExplicit string `xml:"itunes:explicit,omitempty"`
+	ITunesImage    *itunesImage
+	Items          []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	XMLName xml.Name `xml:"item"`
+	*feeds.RssItem
+	DCCreator      string `xml:"dc:creator,omitempty"`
+	ITunesDuration string `xml:"itunes:duration,omitempty"`
+	ITunesImage    *itunesImage
+	MediaGroup     *mediaGroup
+	Categories     []string `xml:"category"`
+}
+
+type itunesImage struct {
+	XMLName xml.Name `xml:"itunes:image"`
+	Href    string   `xml:"href,attr"`
+}
+
+// mediaGroup lists all images and videos of an item in Media RSS
+type mediaGroup struct {
+	XMLName  xml.Name `xml:"media:group"`
+	Contents []*mediaContent
+}
+
+type mediaContent struct {
+	XMLName   xml.Name `xml:"media:content"`
+	URL       string   `xml:"url,attr"`
+	Type      string   `xml:"type,attr,omitempty"`
+	Medium    string   `xml:"medium,attr"`
+	FileSize  int64    `xml:"fileSize,attr,omitempty"`
+	Duration  int      `xml:"duration,attr,omitempty"`
+	Width     int      `xml:"width,attr,omitempty"`
+	Height    int      `xml:"height,attr,omitempty"`
+	Thumbnail *mediaThumbnail
+}
+
+type mediaThumbnail struct {
+	XMLName xml.Name `xml:"media:thumbnail"`
+	URL     string   `xml:"url,attr"`
+}
+
+// newRSSFeedXML converts a generic feed to the extended RSS,
+// items keep the order of the feed items
+func newRSSFeedXML(feed *feeds.Feed) *rssFeedXML {
+	rss := (&feeds.Rss{Feed: feed}).RssFeed()
+
+	channel := &rssChannel{
+		RssFeed: rss,
+		Items:   make([]*rssItem, 0, len(rss.Items)),
+	}
+
+	for _, item := range rss.Items {
+		channel.Items = append(channel.Items, &rssItem{RssItem: item})
+	}
+
+	return &rssFeedXML{
+		Version:          "2.0",
+		ContentNamespace: contentNamespace,
+		Channel:          channel,
+	}
+}
+
+// FeedXml implements feeds.XmlFeed
+func (r *rssFeedXML) FeedXml() any {
+	return r
+}
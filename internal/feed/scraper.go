@@ -1,9 +1,13 @@
 package feed
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,14 +25,18 @@ const userAgentDefault = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleW
 type Scraper struct {
 	protocol string
 	host     string
+	ads      *AdClassifier
 }
 
 func NewDefaultScraper() *Scraper {
-	return &Scraper{protocol: tgProtocolDefault, host: tgDomainDefault}
+	return &Scraper{protocol: tgProtocolDefault, host: tgDomainDefault, ads: newAdClassifierFromEnv()}
 }
 
-// Scrape fetches channel data from Telegram
-func (s *Scraper) Scrape(ctx context.Context, username string) (*entity.Channel, error) {
+// Scrape fetches channel data from Telegram, or channel search results if params.Query is set.
+// It follows t.me pagination (?before={postID}) up to params.Depth pages
+// and stops early once params.Limit posts are collected or posts get older than params.MaxAge.
+// nolint: cyclop
+func (s *Scraper) Scrape(ctx context.Context, username string, params entity.ScrapeParams) (*entity.Channel, error) {
 	logger := app.Logger()
 
 	channel := &entity.Channel{
@@ -36,121 +44,328 @@ func (s *Scraper) Scrape(ctx context.Context, username string) (*entity.Channel,
 		URL:      fmt.Sprintf("%s://%s/s/%s", s.protocol, s.host, username),
 	}
 
-	ua := os.Getenv("USER_AGENT")
+	// Posts of the page being currently visited
+	var page []entity.Post
 
-	if ua != "" {
-		ua = userAgentDefault
-	}
+	// Title of the landing page t.me redirects to when there's no public preview
+	var landingTitle string
 
-	c := colly.NewCollector(
-		colly.AllowedDomains(s.host),
-		colly.UserAgent(ua),
-		colly.StdlibContext(ctx),
-	)
+	// Upstream error of the page being currently visited
+	var pageErr error
 
-	c.WithTransport(httpTransport)
+	c := s.newCollector(ctx)
+
+	c.OnHTML(".tgme_page_title", func(e *colly.HTMLElement) {
+		landingTitle = strings.TrimSpace(e.Text)
+	})
 
 	c.OnHTML(".tgme_channel_info_header", func(e *colly.HTMLElement) {
 		channel.Title = e.ChildText(".tgme_channel_info_header_title")
 		channel.ImageURL = e.ChildAttr("img", "src")
 	})
 
-	c.OnHTML(".tgme_widget_message", func(e *colly.HTMLElement) {
-		var err error
-		var post = entity.Post{}
+	c.OnHTML(".tgme_channel_info", func(e *colly.HTMLElement) {
+		extractChannelInfo(e, channel)
+	})
 
-		if post.ID, err = s.extractPostIDFromPath(e.Attr("data-post")); err != nil {
-			logger.Error("Could not get post ID",
-				"path", e.Attr("data-post"),
-				"error", err)
-			return
+	c.OnHTML(".tgme_widget_message", func(e *colly.HTMLElement) {
+		if post, ok := s.extractPost(e, username); ok {
+			page = append(page, post)
 		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		logger.Error("Request error",
+			"url", r.Request.URL.String(),
+			"status", r.StatusCode,
+			"error", err)
+
+		pageErr = upstreamError(r, err)
+	})
+
+	var since time.Time
+
+	if params.MaxAge > 0 {
+		since = time.Now().Add(-params.MaxAge)
+	}
+
+	seen := make(map[int]struct{})
+	pageURL := channelPageURL(channel.URL, params.Query, 0)
+
+	for i := 0; i < max(params.Depth, 1); i++ {
+		page = nil
+		pageErr = nil
 
-		post.URL = fmt.Sprintf("%s://%s/%s/%d", s.protocol, s.host, username, post.ID)
-		post.Title = extractTitle(e)
-		post.ContentHTML, err = e.DOM.Find(".tgme_widget_message_text").Html()
+		if err := c.Visit(pageURL); err != nil {
+			if i == 0 {
+				if pageErr != nil {
+					return nil, fmt.Errorf("could not visit %s: %w", pageURL, pageErr)
+				}
+
+				return nil, fmt.Errorf("could not visit %s: %w: %w", pageURL, entity.ErrUnavailable, err)
+			}
 
-		if err != nil {
-			logger.Error("Could not get HTML post content",
-				"url", post.URL,
+			// Keep what we already have if one of the older pages fails
+			logger.Error("Could not visit a channel page",
+				"url", pageURL,
 				"error", err)
-			return
+
+			break
 		}
 
-		post.Images = extractImages(e)
+		// t.me redirects missing and private channels to their landing page
+		if i == 0 && channel.Title == "" {
+			if landingTitle != "" {
+				return nil, fmt.Errorf("channel %s is %w or not a channel", username, entity.ErrPrivate)
+			}
 
-		if len(post.Images) > 0 {
-			post.Preview = &post.Images[0]
-		} else {
-			post.Preview = extractPreview(e)
+			return nil, fmt.Errorf("channel %s %w", username, entity.ErrNotFound)
 		}
 
-		dtText, exists := e.DOM.Find(".tgme_widget_message_date").Find("time").Attr("datetime")
+		oldest := oldestPost(page)
+		added := 0
+
+		for _, post := range page {
+			if _, ok := seen[post.ID]; ok {
+				continue
+			}
 
-		if !exists {
-			logger.Error("Could not find datetime", "url", post.URL)
-			return
+			seen[post.ID] = struct{}{}
+			channel.Posts = append(channel.Posts, post)
+			added++
 		}
 
-		dt, err := time.Parse(time.RFC3339, dtText)
+		if added == 0 || oldest == nil || oldest.ID <= 1 {
+			break
+		}
 
-		if err != nil {
-			logger.Error("Could not parse post datetime",
-				"url", post.URL,
-				"datetime", dtText,
-				"error", err)
-			return
+		if params.Limit > 0 && len(channel.Posts) >= params.Limit {
+			break
 		}
 
-		post.Datetime = dt
+		if !since.IsZero() && oldest.Datetime.Before(since) {
+			break
+		}
 
-		// Display post deep link in case message content
-		// is unsupported by t.me or this scraper
-		if post.ContentHTML == "" {
-			post.Title = "Message content is unsupported"
+		pageURL = channelPageURL(channel.URL, params.Query, oldest.ID)
+	}
 
-			postDeepLink := fmt.Sprintf(
-				"tg://resolve?domain=%s&post=%d",
-				username, post.ID,
-			)
+	// Pages come newest first while posts on a page go oldest first
+	slices.SortFunc(channel.Posts, func(a, b entity.Post) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
 
-			unsupportedMsgHTML := os.Getenv("UNSUPPORTED_MESSAGE_HTML")
-
-			if unsupportedMsgHTML == "" {
-				unsupportedMsgHTML = fmt.Sprintf(
-					`<p>Message content is unsupported, try opening it in Telegram mobile app or at t.me using the links below.</p><br><br><a href="%s">[Open in Telegram]</a>&nbsp;&bull;&nbsp;<a href="%s">[Open at t.me]</a>`,
-					postDeepLink, post.URL,
-				)
-			} else {
-				unsupportedMsgHTML = strings.ReplaceAll(
-					unsupportedMsgHTML, "{postDeepLink}", postDeepLink,
-				)
-
-				unsupportedMsgHTML = strings.ReplaceAll(
-					unsupportedMsgHTML, "{postURL}", post.URL,
-				)
-			}
+	if !since.IsZero() {
+		channel.Posts = slices.DeleteFunc(channel.Posts, func(p entity.Post) bool {
+			return p.Datetime.Before(since)
+		})
+	}
 
-			post.ContentHTML = unsupportedMsgHTML
-		}
+	if params.Limit > 0 && len(channel.Posts) > params.Limit {
+		channel.Posts = channel.Posts[len(channel.Posts)-params.Limit:]
+	}
+
+	// Only the posts left in the feed are worth it
+	fillMediaSizes(channel.Posts)
+
+	return channel, nil
+}
+
+// channelPageURL builds the URL of a channel page, or of a search results page if the query is set,
+// with posts published before the given one if it's set
+func channelPageURL(channelURL string, query string, before int) string {
+	qp := url.Values{}
+
+	if query != "" {
+		qp.Set("q", query)
+	}
+
+	if before > 0 {
+		qp.Set("before", strconv.Itoa(before))
+	}
+
+	if len(qp) == 0 {
+		return channelURL
+	}
+
+	return channelURL + "?" + qp.Encode()
+}
+
+// ScrapePost fetches a single post from its embed page (t.me/{username}/{postID}?embed=1),
+// which is available even for posts far back in the channel history.
+// The post is returned as the only one in the channel.
+func (s *Scraper) ScrapePost(ctx context.Context, username string, postID int) (*entity.Channel, error) {
+	logger := app.Logger()
 
-		channel.Posts = append(channel.Posts, post)
+	channel := &entity.Channel{
+		Username: username,
+		URL:      fmt.Sprintf("%s://%s/s/%s", s.protocol, s.host, username),
+	}
+
+	postURL := fmt.Sprintf("%s://%s/%s/%d?embed=1", s.protocol, s.host, username, postID)
+
+	c := s.newCollector(ctx)
+
+	c.OnHTML(".tgme_widget_message", func(e *colly.HTMLElement) {
+		// Embed pages have no channel header, the owner is shown in the message instead
+		channel.Title = e.ChildText(".tgme_widget_message_owner_name")
+		channel.ImageURL = e.ChildAttr(".tgme_widget_message_user_photo img", "src")
+
+		if post, ok := s.extractPost(e, username); ok {
+			channel.Posts = append(channel.Posts, post)
+		}
 	})
 
+	var postErr error
+
 	c.OnError(func(r *colly.Response, err error) {
 		logger.Error("Request error",
-			"url", channel.URL,
+			"url", postURL,
 			"status", r.StatusCode,
 			"error", err)
+
+		postErr = upstreamError(r, err)
 	})
 
-	if err := c.Visit(channel.URL); err != nil {
-		return nil, fmt.Errorf("could not visit %s: %w", channel.URL, err)
+	if err := c.Visit(postURL); err != nil {
+		if postErr != nil {
+			return nil, fmt.Errorf("could not visit %s: %w", postURL, postErr)
+		}
+
+		return nil, fmt.Errorf("could not visit %s: %w: %w", postURL, entity.ErrUnavailable, err)
+	}
+
+	if len(channel.Posts) == 0 {
+		return nil, fmt.Errorf("post %d in channel %s %w", postID, username, entity.ErrNotFound)
 	}
 
+	fillMediaSizes(channel.Posts)
+
 	return channel, nil
 }
 
+// extractPost parses a message widget, which is the same at t.me/s and embed pages.
+// Failures are logged and reported as not ok.
+func (s *Scraper) extractPost(e *colly.HTMLElement, username string) (entity.Post, bool) {
+	logger := app.Logger()
+
+	var err error
+	var post = entity.Post{}
+
+	if post.ID, err = s.extractPostIDFromPath(e.Attr("data-post")); err != nil {
+		logger.Error("Could not get post ID",
+			"path", e.Attr("data-post"),
+			"error", err)
+		return post, false
+	}
+
+	post.URL = fmt.Sprintf("%s://%s/%s/%d", s.protocol, s.host, username, post.ID)
+	post.Title = extractTitle(e)
+	post.Author = strings.TrimSpace(e.DOM.Find(".tgme_widget_message_from_author").First().Text())
+	post.ContentHTML, err = e.DOM.Find(".tgme_widget_message_text").Html()
+
+	if err != nil {
+		logger.Error("Could not get HTML post content",
+			"url", post.URL,
+			"error", err)
+		return post, false
+	}
+
+	post.Images = extractImages(e)
+	post.LinkPreview = extractLinkPreview(e)
+	post.Videos = extractVideos(e)
+	post.Audios = extractAudios(e)
+	post.Attachments = extractAttachments(e)
+	post.Poll = extractPoll(e)
+	post.ForwardedFrom = extractForward(e)
+	post.ReplyTo = extractReply(e)
+	post.Views = parseCount(e.DOM.Find(".tgme_widget_message_views").First().Text())
+	post.Reactions = extractReactions(e)
+	post.Edited = isEdited(e)
+	post.Tags = extractTags(e)
+
+	if post.Poll != nil && post.Poll.Question != "" {
+		post.Title = formatTitle(post.Poll.Question)
+	}
+
+	if len(post.Images) > 0 {
+		post.Preview = &post.Images[0]
+	} else {
+		post.Preview = extractPreview(post.LinkPreview)
+	}
+
+	dtText, exists := e.DOM.Find(".tgme_widget_message_date").Find("time").Attr("datetime")
+
+	if !exists {
+		logger.Error("Could not find datetime", "url", post.URL)
+		return post, false
+	}
+
+	dt, err := time.Parse(time.RFC3339, dtText)
+
+	if err != nil {
+		logger.Error("Could not parse post datetime",
+			"url", post.URL,
+			"datetime", dtText,
+			"error", err)
+		return post, false
+	}
+
+	post.Datetime = dt
+	post.Sponsored = s.ads.IsSponsored(post)
+
+	// Display post deep link in case message content
+	// is unsupported by t.me or this scraper
+	if isEmptyPost(&post) {
+		post.Title = "Message content is unsupported"
+
+		postDeepLink := fmt.Sprintf(
+			"tg://resolve?domain=%s&post=%d",
+			username, post.ID,
+		)
+
+		unsupportedMsgHTML := os.Getenv("UNSUPPORTED_MESSAGE_HTML")
+
+		if unsupportedMsgHTML == "" {
+			unsupportedMsgHTML = fmt.Sprintf(
+				`<p>Message content is unsupported, try opening it in Telegram mobile app or at t.me using the links below.</p><br><br><a href="%s">[Open in Telegram]</a>&nbsp;&bull;&nbsp;<a href="%s">[Open at t.me]</a>`,
+				postDeepLink, post.URL,
+			)
+		} else {
+			unsupportedMsgHTML = strings.ReplaceAll(
+				unsupportedMsgHTML, "{postDeepLink}", postDeepLink,
+			)
+
+			unsupportedMsgHTML = strings.ReplaceAll(
+				unsupportedMsgHTML, "{postURL}", post.URL,
+			)
+		}
+
+		post.ContentHTML = unsupportedMsgHTML
+	}
+
+	return post, true
+}
+
+// newCollector creates a collector for t.me pages
+func (s *Scraper) newCollector(ctx context.Context) *colly.Collector {
+	ua := os.Getenv("USER_AGENT")
+
+	if ua != "" {
+		ua = userAgentDefault
+	}
+
+	c := colly.NewCollector(
+		colly.AllowedDomains(hostname(s.host)),
+		colly.UserAgent(ua),
+		colly.StdlibContext(ctx),
+	)
+
+	c.WithTransport(httpTransport)
+
+	return c
+}
+
 // extractIDFromPath extracts the numeric ID from a string in the format "prefix/id".
 func (s *Scraper) extractPostIDFromPath(path string) (int, error) {
 	parts := strings.Split(path, "/")
@@ -171,3 +386,77 @@ func (s *Scraper) extractPostIDFromPath(path string) (int, error) {
 
 	return id, nil
 }
+
+// isEmptyPost reports whether nothing but images was extracted from the post
+func isEmptyPost(post *entity.Post) bool {
+	return post.ContentHTML == "" &&
+		len(post.Videos) == 0 &&
+		len(post.Audios) == 0 &&
+		len(post.Attachments) == 0 &&
+		post.Poll == nil
+}
+
+// oldestPost returns the post with the lowest ID on a page
+func oldestPost(posts []entity.Post) *entity.Post {
+	var oldest *entity.Post
+
+	for i := range posts {
+		if oldest == nil || posts[i].ID < oldest.ID {
+			oldest = &posts[i]
+		}
+	}
+
+	return oldest
+}
+
+// hostname strips an optional port from the host, as colly matches allowed domains by hostname
+func hostname(host string) string {
+	u, err := url.Parse("//" + host)
+
+	if err != nil {
+		return host
+	}
+
+	return u.Hostname()
+}
+
+// upstreamError converts a failed t.me request to one of the entity errors
+func upstreamError(r *colly.Response, err error) error {
+	if r == nil || r.StatusCode == 0 {
+		return fmt.Errorf("%w: %w", entity.ErrUnavailable, err)
+	}
+
+	switch {
+	case r.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", entity.ErrNotFound, err)
+	case r.StatusCode == http.StatusTooManyRequests:
+		retryErr := &entity.RetryableError{Err: fmt.Errorf("%w: %w", entity.ErrRateLimited, err)}
+
+		if r.Headers != nil {
+			retryErr.RetryAfter = parseRetryAfter(r.Headers.Get("Retry-After"))
+		}
+
+		return retryErr
+	case r.StatusCode == http.StatusServiceUnavailable || r.StatusCode == http.StatusGatewayTimeout:
+		return fmt.Errorf("%w: %w", entity.ErrUnavailable, err)
+	default:
+		return fmt.Errorf("%w: %w", entity.ErrUpstream, err)
+	}
+}
+
+// parseRetryAfter parses the Retry-After header given either in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && time.Until(date) > 0 {
+		return time.Until(date).Round(time.Second)
+	}
+
+	return 0
+}
@@ -0,0 +1,269 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nDmitry/tgfeed/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testChannelPosts   = 30
+	testChannelPerPage = 10
+)
+
+// newTestChannelServer serves a fake t.me/s channel page with paginated posts
+// and embed pages of single posts, where post N was published N hours after the epoch of the test channel.
+// Channels "missing" and "private" are redirected to their landing pages like t.me does,
+// "busy" is rate limited and "broken" fails with a server error.
+func newTestChannelServer(t *testing.T, epoch time.Time) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/s/missing", "/s/private":
+			http.Redirect(w, r, strings.TrimPrefix(r.URL.Path, "/s"), http.StatusFound)
+			return
+		case "/missing":
+			_, _ = w.Write([]byte(`<html><body><div class="tgme_page"><div class="tgme_page_icon"></div></div></body></html>`))
+			return
+		case "/private":
+			_, _ = w.Write([]byte(`<html><body><div class="tgme_page"><div class="tgme_page_title"><span>Private</span></div></div></body></html>`))
+			return
+		case "/s/busy":
+			w.Header().Set("Retry-After", "42")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "/s/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Query().Get("embed") == "1" {
+			id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/testch/"))
+
+			if err != nil || id < 1 || id > testChannelPosts {
+				_, _ = w.Write([]byte(`<html><body><div class="tgme_widget_embed_alert">Post not found</div></body></html>`))
+				return
+			}
+
+			fmt.Fprintf(w, `<html><body><div class="tgme_widget_message" data-post="testch/%d">`, id)
+			fmt.Fprint(w, `<div class="tgme_widget_message_user_photo"><img src="https://example.com/avatar.jpg"></div>`)
+			fmt.Fprint(w, `<a class="tgme_widget_message_owner_name"><span>Test channel</span></a>`)
+			fmt.Fprintf(w, `<div class="tgme_widget_message_text">Post %d</div>`, id)
+			fmt.Fprintf(w, `<a class="tgme_widget_message_date"><time datetime="%s"></time></a></div></body></html>`,
+				epoch.Add(time.Duration(id)*time.Hour).Format(time.RFC3339))
+
+			return
+		}
+
+		before := testChannelPosts + 1
+
+		if b := r.URL.Query().Get("before"); b != "" {
+			before, _ = strconv.Atoi(b)
+		}
+
+		var sb strings.Builder
+
+		sb.WriteString(`<html><body><div class="tgme_channel_info"><div class="tgme_channel_info_header">`)
+		sb.WriteString(`<div class="tgme_channel_info_header_title"><span>Test channel</span><i class="verified-icon"></i></div></div>`)
+		sb.WriteString(`<div class="tgme_channel_info_description">Channel for tests</div>`)
+		sb.WriteString(`<div class="tgme_channel_info_counters">`)
+		sb.WriteString(`<div class="tgme_channel_info_counter"><span class="counter_value">1.5K</span> <span class="counter_type">subscribers</span></div>`)
+		sb.WriteString(`<div class="tgme_channel_info_counter"><span class="counter_value">1</span> <span class="counter_type">photo</span></div>`)
+		sb.WriteString(`<div class="tgme_channel_info_counter"><span class="counter_value">12</span> <span class="counter_type">links</span></div>`)
+		sb.WriteString(`</div></div>`)
+
+		query := r.URL.Query().Get("q")
+
+		for id := max(before-testChannelPerPage, 1); id < before; id++ {
+			// Only ads have a hashtag to search for
+			if query != "" && (query != "#реклама" || id%10 != 7) {
+				continue
+			}
+
+			fmt.Fprintf(&sb, `<div class="tgme_widget_message" data-post="testch/%d">`, id)
+			fmt.Fprintf(&sb, `<div class="tgme_widget_message_text">Post %d`, id)
+
+			// Every tenth post is an ad
+			if id%10 == 7 {
+				sb.WriteString(`<br><a href="?q=%23реклама">#реклама</a>`)
+			}
+
+			sb.WriteString(`</div>`)
+			fmt.Fprintf(&sb, `<a class="tgme_widget_message_date"><time datetime="%s"></time></a></div>`,
+				epoch.Add(time.Duration(id)*time.Hour).Format(time.RFC3339))
+		}
+
+		sb.WriteString(`</body></html>`)
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(sb.String()))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestScraper_ScrapePagination(t *testing.T) {
+	// Post 30 is an hour old, post 1 is 30 hours old
+	epoch := time.Now().Add(-(testChannelPosts + 1) * time.Hour)
+	srv := newTestChannelServer(t, epoch)
+	scraper := &Scraper{protocol: "http", host: strings.TrimPrefix(srv.URL, "http://")}
+
+	tests := []struct {
+		name        string
+		params      entity.ScrapeParams
+		expectedIDs [2]int // first and last post ID
+	}{
+		{
+			name:        "Single page by default",
+			params:      entity.ScrapeParams{},
+			expectedIDs: [2]int{21, 30},
+		},
+		{
+			name:        "Several pages",
+			params:      entity.ScrapeParams{Depth: 2},
+			expectedIDs: [2]int{11, 30},
+		},
+		{
+			name:        "Stops at the beginning of the channel",
+			params:      entity.ScrapeParams{Depth: 10},
+			expectedIDs: [2]int{1, 30},
+		},
+		{
+			name:        "Stops at the post limit",
+			params:      entity.ScrapeParams{Depth: 10, Limit: 15},
+			expectedIDs: [2]int{16, 30},
+		},
+		{
+			name:        "Stops at the max age",
+			params:      entity.ScrapeParams{Depth: 10, MaxAge: 12*time.Hour + 30*time.Minute},
+			expectedIDs: [2]int{19, 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel, err := scraper.Scrape(context.Background(), "testch", tt.params)
+			require.NoError(t, err)
+			require.NotEmpty(t, channel.Posts)
+
+			assert.Equal(t, "Test channel", channel.Title)
+			assert.Equal(t, tt.expectedIDs[0], channel.Posts[0].ID)
+			assert.Equal(t, tt.expectedIDs[1], channel.Posts[len(channel.Posts)-1].ID)
+			assert.Len(t, channel.Posts, tt.expectedIDs[1]-tt.expectedIDs[0]+1)
+
+			for _, p := range channel.Posts {
+				assert.Equal(t, p.ID%10 == 7, p.Sponsored, "Post %d", p.ID)
+			}
+		})
+	}
+}
+
+func TestScraper_ScrapeSearch(t *testing.T) {
+	srv := newTestChannelServer(t, time.Now().Add(-(testChannelPosts+1)*time.Hour))
+	scraper := &Scraper{protocol: "http", host: strings.TrimPrefix(srv.URL, "http://")}
+
+	channel, err := scraper.Scrape(context.Background(), "testch", entity.ScrapeParams{Depth: 10, Query: "#реклама"})
+	require.NoError(t, err)
+
+	ids := make([]int, 0, len(channel.Posts))
+
+	for _, p := range channel.Posts {
+		ids = append(ids, p.ID)
+		assert.Equal(t, []string{"реклама"}, p.Tags)
+	}
+
+	assert.Equal(t, []int{7, 17, 27}, ids)
+}
+
+func TestChannelPageURL(t *testing.T) {
+	assert.Equal(t, "https://t.me/s/testch", channelPageURL("https://t.me/s/testch", "", 0))
+	assert.Equal(t, "https://t.me/s/testch?before=42", channelPageURL("https://t.me/s/testch", "", 42))
+	assert.Equal(t, "https://t.me/s/testch?before=42&q=%23news", channelPageURL("https://t.me/s/testch", "#news", 42))
+}
+
+func TestScraper_ScrapeChannelInfo(t *testing.T) {
+	srv := newTestChannelServer(t, time.Now().Add(-(testChannelPosts+1)*time.Hour))
+	scraper := &Scraper{protocol: "http", host: strings.TrimPrefix(srv.URL, "http://")}
+
+	channel, err := scraper.Scrape(context.Background(), "testch", entity.ScrapeParams{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Test channel", channel.Title)
+	assert.Equal(t, "Channel for tests", channel.Description)
+	assert.True(t, channel.Verified)
+	assert.Equal(t, 1500, channel.Subscribers)
+	assert.Equal(t, 1, channel.Photos)
+	assert.Equal(t, 0, channel.Videos)
+	assert.Equal(t, 12, channel.Links)
+}
+
+func TestScraper_ScrapePost(t *testing.T) {
+	srv := newTestChannelServer(t, time.Now().Add(-(testChannelPosts+1)*time.Hour))
+	scraper := &Scraper{protocol: "http", host: strings.TrimPrefix(srv.URL, "http://")}
+
+	channel, err := scraper.ScrapePost(context.Background(), "testch", 5)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Test channel", channel.Title)
+	assert.Equal(t, "https://example.com/avatar.jpg", channel.ImageURL)
+	require.Len(t, channel.Posts, 1)
+	assert.Equal(t, 5, channel.Posts[0].ID)
+	assert.Equal(t, "Post 5", channel.Posts[0].ContentHTML)
+	assert.Equal(t, srv.URL+"/testch/5", channel.Posts[0].URL)
+
+	_, err = scraper.ScrapePost(context.Background(), "testch", 100)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+}
+
+func TestScraper_ScrapeErrors(t *testing.T) {
+	srv := newTestChannelServer(t, time.Now().Add(-(testChannelPosts+1)*time.Hour))
+	scraper := &Scraper{protocol: "http", host: strings.TrimPrefix(srv.URL, "http://")}
+
+	tests := []struct {
+		username    string
+		expectedErr error
+	}{
+		{username: "missing", expectedErr: entity.ErrNotFound},
+		{username: "private", expectedErr: entity.ErrPrivate},
+		{username: "busy", expectedErr: entity.ErrRateLimited},
+		{username: "broken", expectedErr: entity.ErrUpstream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			_, err := scraper.Scrape(context.Background(), tt.username, entity.ScrapeParams{})
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+
+	_, err := scraper.Scrape(context.Background(), "busy", entity.ScrapeParams{})
+
+	var retryErr *entity.RetryableError
+
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 42*time.Second, retryErr.RetryAfter)
+
+	unreachable := &Scraper{protocol: "http", host: "127.0.0.1:1"}
+
+	_, err = unreachable.Scrape(context.Background(), "testch", entity.ScrapeParams{})
+	require.ErrorIs(t, err, entity.ErrUnavailable)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+	assert.InDelta(t, float64(time.Minute), float64(parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))), float64(2*time.Second))
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nDmitry/tgfeed/internal/entity"
 	"github.com/nDmitry/tgfeed/internal/feed"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,7 +23,7 @@ func TestScraper_Scrape(t *testing.T) {
 	username := "tgfeedtestch"
 
 	// Run the scraper
-	channel, err := scraper.Scrape(ctx, username)
+	channel, err := scraper.Scrape(ctx, username, entity.ScrapeParams{Depth: 1})
 
 	// Basic assertions
 	require.NoError(t, err)